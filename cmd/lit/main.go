@@ -1,25 +1,59 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/carlosarraes/lit/internal/agent"
+	"github.com/carlosarraes/lit/internal/commands"
 	"github.com/carlosarraes/lit/internal/config"
+	"github.com/carlosarraes/lit/internal/input"
+	"github.com/carlosarraes/lit/internal/permissions"
+	"github.com/carlosarraes/lit/internal/prompt"
 	"github.com/carlosarraes/lit/internal/provider"
+	"github.com/carlosarraes/lit/internal/session"
 	"github.com/carlosarraes/lit/internal/tools"
+	"golang.org/x/term"
 )
 
 var version = "dev"
 
 func main() {
 	var initConfig bool
+	var prompt string
+	var maxTurns int
+	var confirm string
+	var outputFormat string
+	var continueSession bool
+	var resumeID string
+	var systemPromptFile string
+	var planMode bool
 	flag.BoolVar(&initConfig, "init", false, "Create default configuration file")
+	flag.StringVar(&prompt, "p", "", "Run a single prompt non-interactively and print the final answer")
+	flag.IntVar(&maxTurns, "max-turns", 0, "Maximum number of model turns per prompt (0 means no limit)")
+	flag.StringVar(&confirm, "confirm", "", "How to answer tool confirmations: ask, approve or deny (default: ask, or deny in print mode without a terminal)")
+	flag.StringVar(&outputFormat, "output-format", "text", "Output format for print mode: text, json (single summary object) or stream-json (newline-delimited events)")
+	flag.BoolVar(&continueSession, "continue", false, "Continue the most recent session in the current directory")
+	flag.BoolVar(&continueSession, "c", false, "Shorthand for --continue")
+	flag.StringVar(&resumeID, "resume", "", "Resume the session with the given id (see 'lit sessions')")
+	flag.StringVar(&systemPromptFile, "system-prompt-file", "", "Replace the built-in system prompt with the contents of this file")
+	flag.BoolVar(&planMode, "plan", false, "Start in plan mode: only read-only tools run and the model proposes a plan")
 	flag.Parse()
 
+	if flag.Arg(0) == "sessions" {
+		if err := listSessions(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if initConfig {
 		if err := config.CreateDefaultConfig(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating default config: %v\n", err)
@@ -43,32 +77,245 @@ func main() {
 		os.Exit(1)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
+	// Without a terminal there is no one to chat with, so run in print mode,
+	// but only read the prompt from stdin when it is a pipe or a file.
+	stdinTerminal := term.IsTerminal(int(os.Stdin.Fd()))
+	printMode := prompt != "" || !stdinTerminal
 
-	getUserMessage := func() (string, bool) {
-		if !scanner.Scan() {
-			return "", false
+	if printMode {
+		if !stdinTerminal && stdinHasInput() {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+				os.Exit(1)
+			}
+			if piped := strings.TrimSpace(string(data)); piped != "" {
+				if prompt == "" {
+					prompt = piped
+				} else {
+					prompt += "\n\n" + piped
+				}
+			}
+		}
+
+		if strings.TrimSpace(prompt) == "" {
+			fmt.Fprintln(os.Stderr, "Error: no prompt provided (use -p or pipe it through stdin)")
+			os.Exit(2)
+		}
+
+		if confirm == "" && !stdinTerminal {
+			confirm = "deny"
+		}
+	}
+
+	switch outputFormat {
+	case "text":
+	case "json", "stream-json":
+		if !printMode {
+			fmt.Fprintf(os.Stderr, "Error: --output-format %s requires print mode (-p)\n", outputFormat)
+			os.Exit(2)
 		}
-		return scanner.Text(), true
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid output format: %s (supported: text, json, stream-json)\n", outputFormat)
+		os.Exit(2)
+	}
+
+	confirmMode, err := permissions.ParseMode(confirm)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
 	}
 
+	getUserMessage := func() (string, bool) {
+		line, err := input.ReadStdinLine(context.Background())
+		return line, err == nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting working directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	tools.SetBashSettings(tools.BashSettings{
+		Root:     cwd,
+		Sandbox:  cfg.Tools.Bash.Sandbox,
+		Writable: cfg.Tools.Bash.Writable,
+	})
+
 	tools := []tools.ToolDefinition{
 		tools.ReadFileDefinition,
 		tools.ListFilesDefinition,
 		tools.EditFileDefinition,
+		tools.MultiEditDefinition,
+		tools.ApplyPatchDefinition,
+		tools.WriteFileDefinition,
 		tools.RipgrepDefinition,
 		tools.FdDefinition,
 		tools.RmDefinition,
 		tools.MvDefinition,
+		tools.BashDefinition,
 		tools.GitStatusDefinition,
 		tools.GitAddDefinition,
 		tools.GitCommitDefinition,
 		tools.GitDiffDefinition,
 	}
 
+	var sess *session.Session
+	var history []provider.Message
+	if continueSession || resumeID != "" {
+		sess, history, err = session.Resume(cwd, resumeID)
+	} else {
+		sess, err = session.Create(cwd, prov.GetModel())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening session: %v\n", err)
+		os.Exit(1)
+	}
+
+	systemPrompt, err := buildSystemPrompt(cfg, systemPromptFile, cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building system prompt: %v\n", err)
+		os.Exit(1)
+	}
+
+	projectSettings, err := config.LoadProjectSettings(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading project settings: %v\n", err)
+		os.Exit(1)
+	}
+
+	checker, err := permissions.NewChecker(cwd, confirmMode, cfg.Permissions, projectSettings.Permissions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading permissions: %v\n", err)
+		os.Exit(1)
+	}
+
 	agent := agent.NewAgent(prov, getUserMessage, tools)
-	if err := agent.Run(context.TODO()); err != nil {
+	agent.SetMaxTurns(maxTurns)
+	agent.SetContextLimits(cfg.Context.Window, cfg.Context.CompactThreshold)
+	agent.SetSystemPrompt(systemPrompt)
+	agent.SetPermissions(checker)
+	agent.SetPlanMode(planMode)
+	agent.SetToolTimeouts(toolTimeouts(cfg.Tools))
+
+	templates, err := commands.Load(commands.Dirs(cwd))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	agent.Commands().RegisterTemplates(templates)
+	agent.SetSession(sess, history)
+
+	if printMode {
+		code := runPrompt(agent, prompt, outputFormat)
+		sess.Close()
+		os.Exit(code)
+	}
+
+	err = agent.Run(context.Background())
+	sess.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running agent: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func toolTimeouts(cfg config.ToolsConfig) (time.Duration, map[string]time.Duration) {
+	timeouts := make(map[string]time.Duration, len(cfg.Timeouts))
+	for name, seconds := range cfg.Timeouts {
+		timeouts[name] = time.Duration(seconds) * time.Second
+	}
+	return time.Duration(cfg.Timeout) * time.Second, timeouts
+}
+
+func buildSystemPrompt(cfg *config.Config, systemPromptFile, cwd string) (string, error) {
+	override := cfg.SystemPrompt
+	if systemPromptFile == "" {
+		systemPromptFile = cfg.SystemPromptFile
+	}
+	if systemPromptFile != "" {
+		content, err := prompt.LoadFile(systemPromptFile)
+		if err != nil {
+			return "", err
+		}
+		override = content
+	}
+
+	return prompt.Build(prompt.Options{Override: override, Cwd: cwd})
+}
+
+func listSessions() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := session.List(cwd)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions found for this directory.")
+		return nil
+	}
+
+	for _, s := range sessions {
+		firstPrompt := strings.Join(strings.Fields(s.FirstPrompt), " ")
+		if len(firstPrompt) > 60 {
+			firstPrompt = firstPrompt[:57] + "..."
+		}
+		fmt.Printf("%s  %s  %-24s  %3d msgs  %s\n", s.ID, s.Updated.Format("2006-01-02 15:04"), s.Model, s.Messages, firstPrompt)
+	}
+	return nil
+}
+
+// stdinHasInput reports whether stdin is a pipe or a file to read the prompt
+// from. Other non-terminal stdins, like /dev/null or an inherited socket in
+// CI, are never read, since reading them could block forever.
+func stdinHasInput() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	mode := info.Mode()
+	return mode&os.ModeNamedPipe != 0 || mode.IsRegular()
+}
+
+func runPrompt(a *agent.Agent, prompt, outputFormat string) int {
+	var result agent.Event
+	switch outputFormat {
+	case "stream-json":
+		a.SetEventHandler(agent.NewJSONEventWriter(os.Stdout))
+	case "json":
+		a.SetEventHandler(func(event agent.Event) {
+			if event.Type == agent.EventResult {
+				result = event
+			}
+		})
+	}
+
+	answer, err := a.RunPrompt(context.Background(), prompt)
+
+	switch outputFormat {
+	case "json":
+		agent.NewJSONEventWriter(os.Stdout)(result)
+	case "text":
+		if answer != "" {
+			fmt.Println(answer)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, agent.ErrMaxTurns) {
+			return 3
+		}
+		if errors.Is(err, context.Canceled) {
+			return 130
+		}
+		return 1
+	}
+	return 0
+}
@@ -3,157 +3,407 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"regexp"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/carlosarraes/lit/internal/input"
+	"github.com/carlosarraes/lit/internal/permissions"
 	"github.com/carlosarraes/lit/internal/provider"
+	"github.com/carlosarraes/lit/internal/session"
 	"github.com/carlosarraes/lit/internal/tools"
 )
 
 const MODEL = "Lit"
 
+var ErrMaxTurns = errors.New("maximum number of turns reached")
+
 type Agent struct {
 	provider       provider.Provider
 	getUserMessage func() (string, bool)
 	tools          []tools.ToolDefinition
 	useInteractive bool
+	printMode      bool
+	maxTurns       int
+	systemPrompt   string
+	commands       *CommandRegistry
+	allowedTools   map[string]bool
+	permissions    *permissions.Checker
+	planMode       bool
+	timeout        time.Duration
+	timeouts       map[string]time.Duration
+	conversation   []provider.Message
+	onEvent        func(Event)
+	session        *session.Session
+	usage          provider.Usage
+	turns          int
+	interrupt      context.CancelFunc
+
+	contextWindow    int
+	compactThreshold float64
 }
 
 func NewAgent(prov provider.Provider, getUserMessage func() (string, bool), tools []tools.ToolDefinition) *Agent {
+	commands := NewCommandRegistry()
+	for _, cmd := range builtinCommands() {
+		commands.Register(cmd)
+	}
+
 	return &Agent{
 		provider:       prov,
 		getUserMessage: getUserMessage,
 		tools:          tools,
 		useInteractive: true,
+		commands:       commands,
 	}
 }
 
-func (a *Agent) Run(ctx context.Context) error {
-	conversation := []provider.Message{}
+func (a *Agent) SetMaxTurns(maxTurns int) {
+	a.maxTurns = maxTurns
+}
+
+func (a *Agent) SetPermissions(checker *permissions.Checker) {
+	a.permissions = checker
+}
+
+// SetToolTimeouts sets the default timeout for tool calls and per-tool
+// overrides. A zero duration disables the timeout.
+func (a *Agent) SetToolTimeouts(timeout time.Duration, timeouts map[string]time.Duration) {
+	a.timeout = timeout
+	a.timeouts = timeouts
+}
+
+func (a *Agent) toolTimeout(name string) time.Duration {
+	if timeout, ok := a.timeouts[name]; ok {
+		return timeout
+	}
+	return a.timeout
+}
+
+func (a *Agent) SetSystemPrompt(systemPrompt string) {
+	a.systemPrompt = systemPrompt
+}
+
+// SetSession persists every new message to s. messages is the history of a
+// resumed session and becomes the starting conversation.
+func (a *Agent) SetSession(s *session.Session, messages []provider.Message) {
+	a.session = s
+	a.conversation = append([]provider.Message{}, messages...)
+}
+
+// restrictTools limits the tools offered to the model until the current
+// prompt has been answered.
+func (a *Agent) restrictTools(names []string) {
+	a.allowedTools = make(map[string]bool, len(names))
+	for _, name := range names {
+		a.allowedTools[name] = true
+	}
+}
+
+func (a *Agent) activeTools() []tools.ToolDefinition {
+	if a.allowedTools == nil {
+		return a.tools
+	}
+
+	active := []tools.ToolDefinition{}
+	for _, tool := range a.tools {
+		if a.allowedTools[tool.Name] {
+			active = append(active, tool)
+		}
+	}
+	return active
+}
+
+func (a *Agent) addMessage(msg provider.Message) {
+	a.conversation = append(a.conversation, msg)
+	if a.session == nil {
+		return
+	}
+	if err := a.session.Append(msg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save session: %v\n", err)
+	}
+}
 
+func (a *Agent) Run(ctx context.Context) error {
 	fmt.Printf("Chat with %s (%s) - use 'ctrl-c' to quit\n", a.provider.GetModel(), MODEL)
+	if a.planMode {
+		fmt.Println("Plan mode on: the model can only investigate and propose a plan. Use /approve to start implementing.")
+	}
+	if a.session != nil && len(a.conversation) > 0 {
+		fmt.Printf("Resumed session %s (%d messages)\n", a.session.ID, len(a.conversation))
+	}
 	var interactiveInput *input.InteractiveInput
 	if a.useInteractive {
 		interactiveInput = input.NewInteractiveInput()
+		interactiveInput.SetCommands(a.commands.Names())
 	}
 
-	readUserInput := true
 	for {
-		if readUserInput {
-			var userInput string
-			var err error
-
-			if a.useInteractive && interactiveInput != nil {
-				userInput, err = interactiveInput.ReadLine()
-				if err == io.EOF {
-					fmt.Println("\nExiting chat.")
-					break
-				} else if err != nil {
-					fmt.Printf("Input error: %v\n", err)
-					continue
-				}
-			} else {
-				fmt.Print("\u001b[94mYou\u001b[0m: ")
-				input, ok := a.getUserMessage()
-				if !ok {
-					fmt.Println("\nExiting chat.")
-					break
-				}
-				userInput = input
-			}
+		var userInput string
+		var err error
 
-			if strings.TrimSpace(userInput) == "" {
+		if a.useInteractive && interactiveInput != nil {
+			userInput, err = interactiveInput.ReadLine()
+			if err == io.EOF {
+				fmt.Println("\nExiting chat.")
+				break
+			} else if err != nil {
+				fmt.Printf("Input error: %v\n", err)
 				continue
 			}
-
-			processedInput := processAtReferences(userInput)
-			conversation = append(conversation, provider.Message{
-				Role:    "user",
-				Content: processedInput,
-			})
+		} else {
+			fmt.Print("\u001b[94mYou\u001b[0m: ")
+			input, ok := a.getUserMessage()
+			if !ok {
+				fmt.Println("\nExiting chat.")
+				break
+			}
+			userInput = input
 		}
 
-		response, err := a.provider.Chat(ctx, conversation, a.tools)
-		if err != nil {
-			return err
+		if strings.TrimSpace(userInput) == "" {
+			continue
 		}
 
-		if response.Content != "" {
-			fmt.Printf("\u001b[93m%s\u001b[0m: %s\n", MODEL, response.Content)
-			conversation = append(conversation, provider.Message{
-				Role:    "assistant",
-				Content: response.Content,
-			})
-		}
-
-		if len(response.ToolCalls) > 0 {
-			toolResults := []string{}
-			for _, toolCall := range response.ToolCalls {
-				result := a.executeTool(toolCall.ID, toolCall.Name, toolCall.Input)
-				toolResults = append(toolResults, result)
+		if name, args, ok := parseCommand(userInput); ok {
+			cmd, found := a.commands.Lookup(name)
+			if !found {
+				fmt.Printf("Unknown command: /%s (type /help to list commands)\n", name)
+				continue
 			}
-			
-			if len(toolResults) > 0 {
-				toolResultsContent := strings.Join(toolResults, "\n\n")
-				conversation = append(conversation, provider.Message{
-					Role:    "user",
-					Content: toolResultsContent,
-				})
-				readUserInput = false
-				fmt.Println()
+
+			prompt, err := cmd.Run(ctx, a, args)
+			if errors.Is(err, errExitChat) {
+				fmt.Println("Exiting chat.")
+				break
+			}
+			if err != nil {
+				fmt.Printf("\u001b[91m%v\u001b[0m\n", err)
 				continue
 			}
+			if strings.TrimSpace(prompt) == "" {
+				continue
+			}
+			userInput = prompt
 		}
 
-		readUserInput = true
+		processedInput := processAtReferences(userInput)
+		a.addMessage(provider.NewTextMessage("user", processedInput))
+		a.emit(Event{Type: EventUser, Text: processedInput})
+
+		_, err = a.runTurns(ctx)
+		a.allowedTools = nil
+		if err != nil {
+			if errors.Is(err, ErrMaxTurns) {
+				fmt.Printf("\u001b[91m%v\u001b[0m\n", err)
+				continue
+			}
+			if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+				fmt.Println("\n\u001b[91mInterrupted.\u001b[0m")
+				continue
+			}
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (a *Agent) executeTool(id, name string, input json.RawMessage) string {
-	var toolDef tools.ToolDefinition
-	var found bool
+// RunPrompt sends a single prompt and keeps executing tool calls until the
+// model answers without requesting more tools. It returns the final answer.
+func (a *Agent) RunPrompt(ctx context.Context, prompt string) (string, error) {
+	a.printMode = true
+	start := time.Now()
 
-	for _, tool := range a.tools {
-		if tool.Name == name {
-			toolDef = tool
-			found = true
-			break
-		}
-	}
+	processedPrompt := processAtReferences(prompt)
+	a.addMessage(provider.NewTextMessage("user", processedPrompt))
+	a.emit(Event{Type: EventUser, Text: processedPrompt})
 
-	if !found {
-		return fmt.Sprintf("Tool %s not found", name)
+	answer := ""
+	response, err := a.runTurns(ctx)
+	if response != nil {
+		answer = response.Content
 	}
 
-	fmt.Printf("\u001b[92mtool\u001b[0m: %s(%s) ", name, input)
-	response, err := toolDef.Function(input)
+	result := Event{
+		Type:       EventResult,
+		Text:       answer,
+		Model:      a.provider.GetModel(),
+		NumTurns:   a.turns,
+		DurationMS: time.Since(start).Milliseconds(),
+		Usage:      &a.usage,
+	}
 	if err != nil {
-		fmt.Println("❌")
-		return fmt.Sprintf("Tool %s failed: %s", name, err.Error())
+		a.emit(Event{Type: EventError, Error: err.Error()})
+		result.IsError = true
+		result.Error = err.Error()
 	}
-	fmt.Println("✅")
+	a.emit(result)
 
-	return response
+	return answer, err
 }
 
+func (a *Agent) runTurns(ctx context.Context) (*provider.Response, error) {
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	ctx, a.interrupt = context.WithCancel(ctx)
+	defer a.interrupt()
+
+	turns := 0
+	for {
+		a.maybeCompact(ctx)
 
-func processAtReferences(input string) string {
-	re := regexp.MustCompile(`@([^\s]+)`)
+		response, err := a.chat(ctx, a.conversation)
+		if err != nil {
+			return nil, err
+		}
+		turns++
+		a.turns++
+		a.usage.InputTokens += response.Usage.InputTokens
+		a.usage.OutputTokens += response.Usage.OutputTokens
+		a.emit(Event{Type: EventUsage, Usage: &response.Usage})
 
-	return re.ReplaceAllStringFunc(input, func(match string) string {
-		path := strings.TrimPrefix(match, "@")
+		if response.Content != "" {
+			a.emit(Event{Type: EventAssistant, Text: response.Content})
+		}
 
-		if strings.Contains(path, "/") ||
-			strings.Contains(path, ".") ||
-			!strings.Contains(path, "@") {
-			return path
+		if response.Content != "" || len(response.ToolCalls) > 0 {
+			a.addMessage(provider.NewAssistantMessage(response))
 		}
 
-		return match
+		if len(response.ToolCalls) == 0 {
+			return response, nil
+		}
+
+		a.addMessage(provider.Message{Role: "user", Content: a.executeToolCalls(ctx, response.ToolCalls)})
+		if err := ctx.Err(); err != nil {
+			return response, err
+		}
+
+		if a.maxTurns > 0 && turns >= a.maxTurns {
+			return response, fmt.Errorf("%w (%d)", ErrMaxTurns, a.maxTurns)
+		}
+
+		if !a.printMode {
+			fmt.Println()
+		}
+	}
+}
+
+func (a *Agent) chat(ctx context.Context, conversation []provider.Message) (*provider.Response, error) {
+	systemPrompt := a.systemPrompt
+	if a.planMode {
+		systemPrompt = strings.TrimSpace(systemPrompt + "\n\n" + planInstruction)
+	}
+
+	messages := conversation
+	if systemPrompt != "" {
+		messages = append([]provider.Message{provider.NewTextMessage("system", systemPrompt)}, conversation...)
+	}
+
+	printing := false
+	response, err := a.provider.ChatStream(ctx, messages, a.activeTools(), func(event provider.StreamEvent) {
+		if a.printMode || event.Type != provider.EventTextDelta || event.Text == "" {
+			return
+		}
+		if !printing {
+			fmt.Printf("\u001b[93m%s\u001b[0m: ", MODEL)
+			printing = true
+		}
+		fmt.Print(event.Text)
 	})
+	if printing {
+		fmt.Println()
+	}
+
+	return response, err
+}
+
+func (a *Agent) findTool(name string) (tools.ToolDefinition, bool) {
+	for _, tool := range a.activeTools() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return tools.ToolDefinition{}, false
+}
+
+func (a *Agent) executeTool(ctx context.Context, id, name string, input json.RawMessage) (string, bool) {
+	toolDef, found := a.findTool(name)
+
+	a.emit(Event{Type: EventToolCall, ToolUseID: id, Name: name, Input: input})
+
+	if !found {
+		result := fmt.Sprintf("Tool %s not found", name)
+		a.emit(Event{Type: EventToolResult, ToolUseID: id, Name: name, Text: result, IsError: true})
+		return result, true
+	}
+
+	if reason, blocked := a.blockedByPlanMode(toolDef); blocked {
+		fmt.Fprintf(a.logOutput(), "\u001b[92mtool\u001b[0m: %s(%s) 📋\n", name, input)
+		a.emit(Event{Type: EventToolResult, ToolUseID: id, Name: name, Text: reason, IsError: true})
+		return reason, true
+	}
+
+	if a.permissions != nil {
+		var paths []string
+		if toolDef.Paths != nil {
+			paths = toolDef.Paths(input)
+		}
+		allowed, reason, err := a.permissions.Authorize(ctx, name, toolDef.ReadOnly, input, paths...)
+		if err != nil {
+			// Ctrl-C at the confirmation prompt reaches us as a key press
+			// rather than a signal, so cancel the turn here.
+			if a.interrupt != nil {
+				a.interrupt()
+			}
+			reason = fmt.Sprintf("Tool %s was interrupted by the user", name)
+		}
+		if !allowed {
+			fmt.Fprintf(a.logOutput(), "\u001b[92mtool\u001b[0m: %s(%s) 🚫\n", name, input)
+			a.emit(Event{Type: EventToolResult, ToolUseID: id, Name: name, Text: reason, IsError: true})
+			return reason, true
+		}
+	}
+
+	toolCtx := ctx
+	if timeout := a.toolTimeout(name); timeout > 0 {
+		var cancel context.CancelFunc
+		toolCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	response, err := toolDef.Function(toolCtx, input)
+	if err == nil && toolCtx.Err() != nil {
+		err = toolCtx.Err()
+	}
+	if err != nil {
+		fmt.Fprintf(a.logOutput(), "\u001b[92mtool\u001b[0m: %s(%s) ❌\n", name, input)
+		result := fmt.Sprintf("Tool %s failed: %s", name, err.Error())
+		switch {
+		case errors.Is(toolCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+			result = fmt.Sprintf("Tool %s timed out after %s", name, a.toolTimeout(name))
+		case errors.Is(ctx.Err(), context.Canceled):
+			result = fmt.Sprintf("Tool %s was interrupted by the user", name)
+		}
+		a.emit(Event{Type: EventToolResult, ToolUseID: id, Name: name, Text: result, IsError: true})
+		return result, true
+	}
+	fmt.Fprintf(a.logOutput(), "\u001b[92mtool\u001b[0m: %s(%s) ✅\n", name, input)
+	a.emit(Event{Type: EventToolResult, ToolUseID: id, Name: name, Text: response})
+
+	return response, false
+}
+
+func (a *Agent) logOutput() io.Writer {
+	if a.onEvent != nil {
+		return io.Discard
+	}
+	if a.printMode {
+		return os.Stderr
+	}
+	return os.Stdout
 }
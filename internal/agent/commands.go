@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carlosarraes/lit/internal/commands"
+	"github.com/carlosarraes/lit/internal/provider"
+)
+
+var errExitChat = errors.New("exit chat")
+
+// Command is a slash command handled by the interactive loop before anything
+// is sent to the model. A non-empty prompt returned by Run is sent to the
+// model as the user's message.
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(ctx context.Context, a *Agent, args string) (prompt string, err error)
+}
+
+type CommandRegistry struct {
+	commands map[string]Command
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{commands: make(map[string]Command)}
+}
+
+func (r *CommandRegistry) Register(cmd Command) {
+	r.commands[cmd.Name] = cmd
+}
+
+// RegisterTemplates adds the custom commands. Templates never replace a
+// built-in command of the same name.
+func (r *CommandRegistry) RegisterTemplates(templates []commands.Template) {
+	for _, t := range templates {
+		if _, exists := r.commands[t.Name]; exists {
+			fmt.Fprintf(os.Stderr, "Warning: custom command /%s from %s conflicts with a built-in command\n", t.Name, t.Path)
+			continue
+		}
+		r.Register(TemplateCommand(t))
+	}
+}
+
+func (r *CommandRegistry) Lookup(name string) (Command, bool) {
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+func (r *CommandRegistry) Names() []string {
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (a *Agent) Commands() *CommandRegistry {
+	return a.commands
+}
+
+// parseCommand splits "/name args" into its parts. ok is false when the
+// input is not a slash command.
+func parseCommand(userInput string) (name, args string, ok bool) {
+	trimmed := strings.TrimSpace(userInput)
+	if !strings.HasPrefix(trimmed, "/") || strings.HasPrefix(trimmed, "//") {
+		return "", "", false
+	}
+
+	name, args, _ = strings.Cut(trimmed[1:], " ")
+	if name == "" || strings.Contains(name, "/") {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(args), true
+}
+
+// TemplateCommand turns a Markdown prompt template into a slash command.
+func TemplateCommand(t commands.Template) Command {
+	description := t.Description
+	if description == "" {
+		description = "Custom command from " + t.Path
+	}
+
+	return Command{
+		Name:        t.Name,
+		Usage:       t.ArgumentHint,
+		Description: description,
+		Run: func(ctx context.Context, a *Agent, args string) (string, error) {
+			if len(t.AllowedTools) > 0 {
+				a.restrictTools(t.AllowedTools)
+			}
+			return t.Expand(args), nil
+		},
+	}
+}
+
+func builtinCommands() []Command {
+	return []Command{
+		{Name: "help", Description: "Show available commands", Run: runHelp},
+		{Name: "clear", Description: "Clear the conversation history", Run: runClear},
+		{Name: "compact", Description: "Summarize the conversation to free up context", Run: runCompact},
+		{Name: "model", Usage: "[name]", Description: "Show or switch the model", Run: runModel},
+		{Name: "tools", Description: "List available tools", Run: runTools},
+		{Name: "plan", Usage: "[on|off]", Description: "Toggle plan mode (read-only investigation)", Run: runPlan},
+		{Name: "approve", Usage: "[notes]", Description: "Approve the plan and start implementing", Run: runApprove},
+		{Name: "cost", Description: "Show token usage for this session", Run: runCost},
+		{Name: "save", Usage: "[path]", Description: "Save the conversation as Markdown", Run: runSave},
+		{Name: "exit", Description: "Exit lit", Run: runExit},
+	}
+}
+
+func runHelp(ctx context.Context, a *Agent, args string) (string, error) {
+	fmt.Println("Available commands:")
+	for _, name := range a.commands.Names() {
+		cmd, _ := a.commands.Lookup(name)
+		usage := "/" + cmd.Name
+		if cmd.Usage != "" {
+			usage += " " + cmd.Usage
+		}
+		fmt.Printf("  \u001b[96m%-20s\u001b[0m %s\n", usage, cmd.Description)
+	}
+	return "", nil
+}
+
+func runClear(ctx context.Context, a *Agent, args string) (string, error) {
+	a.conversation = nil
+	if a.session != nil {
+		if err := a.session.Reset(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save session: %v\n", err)
+		}
+	}
+	fmt.Println("Conversation cleared.")
+	return "", nil
+}
+
+func runCompact(ctx context.Context, a *Agent, args string) (string, error) {
+	before := provider.EstimateConversationTokens(a.conversation)
+	if err := a.compact(ctx, len(a.conversation)); err != nil {
+		return "", fmt.Errorf("compaction failed: %w", err)
+	}
+	fmt.Printf("Compacted conversation: ~%d → ~%d tokens\n", before, provider.EstimateConversationTokens(a.conversation))
+	return "", nil
+}
+
+func runModel(ctx context.Context, a *Agent, args string) (string, error) {
+	if args == "" {
+		fmt.Printf("Current model: %s\n", a.provider.GetModel())
+		return "", nil
+	}
+	a.provider.SetModel(args)
+	fmt.Printf("Switched model to %s\n", args)
+	return "", nil
+}
+
+func runTools(ctx context.Context, a *Agent, args string) (string, error) {
+	fmt.Println("Available tools:")
+	for _, tool := range a.tools {
+		description, _, _ := strings.Cut(strings.TrimSpace(tool.Description), "\n")
+		fmt.Printf("  \u001b[92m%-14s\u001b[0m %s\n", tool.Name, description)
+	}
+	return "", nil
+}
+
+func runCost(ctx context.Context, a *Agent, args string) (string, error) {
+	fmt.Printf("Model:          %s\n", a.provider.GetModel())
+	fmt.Printf("Turns:          %d\n", a.turns)
+	fmt.Printf("Input tokens:   %d\n", a.usage.InputTokens)
+	fmt.Printf("Output tokens:  %d\n", a.usage.OutputTokens)
+	if cost, ok := provider.EstimateCost(a.provider.GetModel(), a.usage); ok {
+		fmt.Printf("Estimated cost: ~$%.4f\n", cost)
+	}
+	fmt.Printf("Context:        ~%d tokens (compacts at ~%d)\n", provider.EstimateConversationTokens(a.conversation), a.contextBudget())
+	return "", nil
+}
+
+func runSave(ctx context.Context, a *Agent, args string) (string, error) {
+	path := args
+	if path == "" {
+		path = fmt.Sprintf("lit-conversation-%s.md", time.Now().Format("20060102-150405"))
+	}
+
+	if err := os.WriteFile(path, []byte(markdownTranscript(a.conversation)), 0644); err != nil {
+		return "", fmt.Errorf("failed to save conversation: %w", err)
+	}
+	fmt.Printf("Conversation saved to %s\n", path)
+	return "", nil
+}
+
+func runExit(ctx context.Context, a *Agent, args string) (string, error) {
+	return "", errExitChat
+}
+
+func markdownTranscript(messages []provider.Message) string {
+	var sb strings.Builder
+	sb.WriteString("# Lit conversation\n")
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case provider.BlockText:
+				title := "You"
+				if msg.Role == "assistant" {
+					title = MODEL
+				}
+				fmt.Fprintf(&sb, "\n## %s\n\n%s\n", title, block.Text)
+			case provider.BlockToolUse:
+				fmt.Fprintf(&sb, "\n### Tool call: %s\n\n```json\n%s\n```\n", block.Name, block.Input)
+			case provider.BlockToolResult:
+				title := "Tool result"
+				if block.IsError {
+					title = "Tool error"
+				}
+				fmt.Fprintf(&sb, "\n### %s\n\n```\n%s\n```\n", title, block.Content)
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/carlosarraes/lit/internal/provider"
+)
+
+const (
+	defaultCompactThreshold = 0.8
+	staleToolResultChars    = 2000
+	transcriptToolChars     = 500
+)
+
+const summaryPrompt = `Summarize the conversation transcript below so the work can continue without it.
+Keep: the user's goals and instructions, decisions made, files read or changed (with paths),
+important findings, commands run and their outcomes, and any open tasks or next steps.
+Be concise and factual. Reply with the summary only.`
+
+func (a *Agent) SetContextLimits(window int, threshold float64) {
+	a.contextWindow = window
+	a.compactThreshold = threshold
+}
+
+func (a *Agent) contextBudget() int {
+	threshold := a.compactThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultCompactThreshold
+	}
+	window := a.contextWindow
+	if window <= 0 {
+		window = provider.ContextWindow(a.provider.GetModel())
+	}
+	return int(float64(window) * threshold)
+}
+
+// maybeCompact compacts the conversation once its estimated size crosses the
+// context budget. The turn that is currently in progress is always kept.
+func (a *Agent) maybeCompact(ctx context.Context) {
+	if provider.EstimateConversationTokens(a.conversation) < a.contextBudget() {
+		return
+	}
+
+	before := provider.EstimateConversationTokens(a.conversation)
+	if err := a.compact(ctx, lastTurnStart(a.conversation)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to compact conversation: %v\n", err)
+		return
+	}
+	fmt.Fprintf(a.logOutput(), "\u001b[90mcompacted conversation: ~%d → ~%d tokens\u001b[0m\n", before, provider.EstimateConversationTokens(a.conversation))
+}
+
+// compact truncates stale tool results before keep and, if the conversation
+// is still over budget, replaces everything before keep with a summary. When
+// there is nothing before keep, the tool results from keep on are truncated
+// instead.
+func (a *Agent) compact(ctx context.Context, keep int) error {
+	conversation := make([]provider.Message, len(a.conversation))
+	copy(conversation, a.conversation)
+
+	for i := 0; i < keep; i++ {
+		conversation[i] = truncateToolResults(conversation[i], staleToolResultChars)
+	}
+
+	force := keep == len(conversation)
+	if !force && keep == 0 && provider.EstimateConversationTokens(conversation) >= a.contextBudget() {
+		before := provider.EstimateConversationTokens(conversation)
+		for i := range conversation {
+			conversation[i] = truncateToolResults(conversation[i], staleToolResultChars)
+		}
+		if provider.EstimateConversationTokens(conversation) == before {
+			return fmt.Errorf("the current turn alone exceeds the context budget")
+		}
+	} else if force || provider.EstimateConversationTokens(conversation) >= a.contextBudget() {
+		if keep == 0 {
+			return fmt.Errorf("nothing to summarize")
+		}
+
+		response, err := a.provider.Chat(ctx, []provider.Message{
+			provider.NewTextMessage("user", summaryPrompt+"\n\n<transcript>\n"+transcript(conversation[:keep])+"\n</transcript>"),
+		}, nil)
+		if err != nil {
+			return err
+		}
+		a.usage.InputTokens += response.Usage.InputTokens
+		a.usage.OutputTokens += response.Usage.OutputTokens
+
+		summary := provider.TextBlock("Summary of the earlier conversation:\n\n" + response.Content)
+		rest := conversation[keep:]
+		if len(rest) > 0 {
+			first := rest[0]
+			first.Content = append([]provider.ContentBlock{summary}, first.Content...)
+			conversation = append([]provider.Message{first}, rest[1:]...)
+		} else {
+			conversation = []provider.Message{{Role: "user", Content: []provider.ContentBlock{summary}}}
+		}
+	}
+
+	a.conversation = conversation
+	if a.session != nil {
+		if err := a.session.Reset(conversation); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save session: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// lastTurnStart returns the index of the latest user message that was typed
+// by the user rather than carrying tool results.
+func lastTurnStart(messages []provider.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" && !messages[i].HasToolResults() {
+			return i
+		}
+	}
+	return 0
+}
+
+// truncatedSuffix ends every truncated tool result, so a result is never
+// truncated twice.
+const truncatedSuffix = " during compaction]"
+
+func truncateToolResults(msg provider.Message, limit int) provider.Message {
+	if !msg.HasToolResults() {
+		return msg
+	}
+
+	blocks := make([]provider.ContentBlock, len(msg.Content))
+	copy(blocks, msg.Content)
+	for i, block := range blocks {
+		if block.Type != provider.BlockToolResult || len(block.Content) <= limit || strings.HasSuffix(block.Content, truncatedSuffix) {
+			continue
+		}
+		kept := truncateUTF8(block.Content, limit)
+		blocks[i].Content = fmt.Sprintf("%s\n... [truncated %d characters%s", kept, len(block.Content)-len(kept), truncatedSuffix)
+	}
+	msg.Content = blocks
+	return msg
+}
+
+func transcript(messages []provider.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case provider.BlockText:
+				fmt.Fprintf(&sb, "[%s] %s\n", msg.Role, block.Text)
+			case provider.BlockToolUse:
+				fmt.Fprintf(&sb, "[tool call] %s(%s)\n", block.Name, block.Input)
+			case provider.BlockToolResult:
+				content := block.Content
+				if len(content) > transcriptToolChars {
+					content = truncateUTF8(content, transcriptToolChars) + "..."
+				}
+				status := "tool result"
+				if block.IsError {
+					status = "tool error"
+				}
+				fmt.Fprintf(&sb, "[%s] %s\n", status, content)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
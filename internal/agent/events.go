@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/carlosarraes/lit/internal/provider"
+)
+
+const (
+	EventUser       = "user"
+	EventAssistant  = "assistant"
+	EventToolCall   = "tool_call"
+	EventToolResult = "tool_result"
+	EventError      = "error"
+	EventUsage      = "usage"
+	EventResult     = "result"
+)
+
+// Event is a structured record of what happens during a run. It is emitted
+// as newline-delimited JSON when lit runs with a structured output format.
+type Event struct {
+	Type       string          `json:"type"`
+	Text       string          `json:"text,omitempty"`
+	ToolUseID  string          `json:"tool_use_id,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Input      json.RawMessage `json:"input,omitempty"`
+	IsError    bool            `json:"is_error,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Usage      *provider.Usage `json:"usage,omitempty"`
+	Model      string          `json:"model,omitempty"`
+	NumTurns   int             `json:"num_turns,omitempty"`
+	DurationMS int64           `json:"duration_ms,omitempty"`
+}
+
+func NewJSONEventWriter(w io.Writer) func(Event) {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+	return func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		encoder.Encode(event)
+	}
+}
+
+func (a *Agent) SetEventHandler(onEvent func(Event)) {
+	a.onEvent = onEvent
+}
+
+func (a *Agent) emit(event Event) {
+	if a.onEvent != nil {
+		a.onEvent(event)
+	}
+}
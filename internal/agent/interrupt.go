@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// interruptible returns a context that is cancelled when the user presses
+// Ctrl-C, so an interrupt stops the current turn instead of the process.
+func interruptible(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(interrupts)
+		cancel()
+	}
+}
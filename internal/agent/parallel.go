@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/carlosarraes/lit/internal/provider"
+)
+
+const maxParallelTools = 8
+
+// executeToolCalls runs the tool calls of one response and returns their
+// results in the original order. Consecutive read-only calls run in
+// parallel; any other call waits for the previous ones and runs alone.
+func (a *Agent) executeToolCalls(ctx context.Context, calls []provider.ToolCall) []provider.ContentBlock {
+	results := make([]provider.ContentBlock, len(calls))
+
+	for start := 0; start < len(calls); {
+		end := start
+		for end < len(calls) && a.isReadOnly(calls[end].Name) {
+			end++
+		}
+
+		if end == start {
+			results[start] = a.executeToolCall(ctx, calls[start])
+			start++
+			continue
+		}
+
+		a.executeParallel(ctx, calls[start:end], results[start:end])
+		start = end
+	}
+
+	return results
+}
+
+func (a *Agent) executeParallel(ctx context.Context, calls []provider.ToolCall, results []provider.ContentBlock) {
+	if len(calls) == 1 {
+		results[0] = a.executeToolCall(ctx, calls[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, maxParallelTools)
+	for i, call := range calls {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, call provider.ToolCall) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = a.executeToolCall(ctx, call)
+		}(i, call)
+	}
+	wg.Wait()
+}
+
+func (a *Agent) executeToolCall(ctx context.Context, call provider.ToolCall) provider.ContentBlock {
+	result, isError := a.executeTool(ctx, call.ID, call.Name, call.Input)
+	return provider.ToolResultBlock(call.ID, result, isError)
+}
+
+func (a *Agent) isReadOnly(name string) bool {
+	tool, found := a.findTool(name)
+	return found && tool.ReadOnly
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carlosarraes/lit/internal/tools"
+)
+
+const planInstruction = `You are in plan mode. Investigate the codebase with the read-only tools and do not modify anything: tools that change files or git state are blocked.
+When you understand the task, present a concise, numbered implementation plan listing the files to change and how. Then stop and wait for the user to approve the plan.`
+
+const approvedPrompt = "The plan is approved. Proceed with the implementation."
+
+func (a *Agent) SetPlanMode(enabled bool) {
+	a.planMode = enabled
+}
+
+func (a *Agent) PlanMode() bool {
+	return a.planMode
+}
+
+func (a *Agent) blockedByPlanMode(tool tools.ToolDefinition) (string, bool) {
+	if !a.planMode || tool.ReadOnly {
+		return "", false
+	}
+	return fmt.Sprintf("Tool %s is not available in plan mode, which only allows read-only tools. Finish investigating, present your plan and wait for the user to approve it before making changes.", tool.Name), true
+}
+
+func runPlan(ctx context.Context, a *Agent, args string) (string, error) {
+	switch args {
+	case "":
+		a.SetPlanMode(!a.planMode)
+	case "on":
+		a.SetPlanMode(true)
+	case "off":
+		a.SetPlanMode(false)
+	default:
+		return "", fmt.Errorf("usage: /plan [on|off]")
+	}
+
+	if a.planMode {
+		fmt.Println("Plan mode on: the model can only investigate and propose a plan. Use /approve to start implementing.")
+	} else {
+		fmt.Println("Plan mode off.")
+	}
+	return "", nil
+}
+
+func runApprove(ctx context.Context, a *Agent, args string) (string, error) {
+	if !a.planMode {
+		return "", fmt.Errorf("not in plan mode")
+	}
+	a.SetPlanMode(false)
+	fmt.Println("Plan approved, switching to normal mode.")
+
+	if args != "" {
+		return approvedPrompt + "\n\n" + args, nil
+	}
+	return approvedPrompt, nil
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/carlosarraes/lit/internal/gitignore"
+	"github.com/carlosarraes/lit/internal/tools"
+)
+
+const (
+	maxReferenceBytes   = 100 * 1024
+	maxReferenceLines   = 2000
+	maxReferenceEntries = 200
+)
+
+var (
+	atReferenceRe = regexp.MustCompile(`(^|\s)@([^\s]+)`)
+	lineRangeRe   = regexp.MustCompile(`^(.+):(\d+)(?:-(\d+))?$`)
+)
+
+type reference struct {
+	path  string
+	start int
+	end   int
+}
+
+// processAtReferences strips the '@' from every @path in the input and
+// attaches the referenced files, line ranges (@path:10-40) and directory
+// listings (@dir/) as context after the message.
+func processAtReferences(userInput string) string {
+	attachments := []string{}
+	seen := map[string]bool{}
+
+	processed := atReferenceRe.ReplaceAllStringFunc(userInput, func(match string) string {
+		prefix, token, _ := strings.Cut(match, "@")
+		raw := strings.TrimRight(token, ".,;!?)")
+		suffix := token[len(raw):]
+
+		ref, ok := parseReference(raw)
+		if !ok {
+			return match
+		}
+
+		if !seen[raw] {
+			seen[raw] = true
+			if attachment, err := attachReference(ref); err == nil {
+				attachments = append(attachments, attachment)
+			}
+		}
+
+		return prefix + raw + suffix
+	})
+
+	if len(attachments) == 0 {
+		return processed
+	}
+	return processed + "\n\n" + strings.Join(attachments, "\n\n")
+}
+
+func parseReference(raw string) (reference, bool) {
+	if _, err := os.Stat(raw); err == nil {
+		return reference{path: raw}, true
+	}
+
+	match := lineRangeRe.FindStringSubmatch(raw)
+	if match == nil {
+		return reference{}, false
+	}
+	if info, err := os.Stat(match[1]); err != nil || info.IsDir() {
+		return reference{}, false
+	}
+
+	start, _ := strconv.Atoi(match[2])
+	end := start
+	if match[3] != "" {
+		end, _ = strconv.Atoi(match[3])
+	}
+	if start < 1 || end < start {
+		return reference{}, false
+	}
+
+	return reference{path: match[1], start: start, end: end}, true
+}
+
+func attachReference(ref reference) (string, error) {
+	info, err := os.Stat(ref.path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return attachDirectory(ref.path)
+	}
+	return attachFile(ref)
+}
+
+func attachFile(ref reference) (string, error) {
+	file, err := os.Open(ref.path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(8000)
+	if bytes.IndexByte(head, 0) != -1 {
+		info, _ := file.Stat()
+		return fmt.Sprintf("<file path=%q>\n[binary file, %d bytes]\n</file>", ref.path, info.Size()), nil
+	}
+
+	var sb strings.Builder
+	lineNumber := 0
+	size := 0
+	truncated := ""
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		lineNumber++
+		if ref.start > 0 && lineNumber < ref.start {
+			continue
+		}
+		if ref.end > 0 && lineNumber > ref.end {
+			break
+		}
+		if size >= maxReferenceBytes || lineNumber-max(ref.start, 1) >= maxReferenceLines {
+			truncated = fmt.Sprintf("... [truncated after line %d, use read_file for the rest]\n", lineNumber-1)
+			break
+		}
+
+		formatted := fmt.Sprintf("%5d\t%s\n", lineNumber, strings.TrimRight(line, "\r\n"))
+		size += len(formatted)
+		sb.WriteString(formatted)
+	}
+	sb.WriteString(truncated)
+
+	// Only a complete attachment counts as a read; edits to a file seen in
+	// part must still read it first.
+	if ref.start == 0 && truncated == "" {
+		tools.MarkFileAsRead(ref.path)
+	}
+
+	if ref.start > 0 {
+		return fmt.Sprintf("<file path=%q lines=\"%d-%d\">\n%s</file>", ref.path, ref.start, ref.end, sb.String()), nil
+	}
+	return fmt.Sprintf("<file path=%q>\n%s</file>", ref.path, sb.String()), nil
+}
+
+func attachDirectory(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	matcher := gitignore.New(".")
+	names := []string{}
+	for _, entry := range entries {
+		if matcher.Ignored(filepath.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > maxReferenceEntries {
+		names = append(names[:maxReferenceEntries], fmt.Sprintf("... (%d more entries)", len(names)-maxReferenceEntries))
+	}
+
+	return fmt.Sprintf("<directory path=%q>\n%s\n</directory>", dir, strings.Join(names, "\n")), nil
+}
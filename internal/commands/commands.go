@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Template is a user-defined slash command loaded from a Markdown file.
+type Template struct {
+	Name         string
+	Description  string
+	ArgumentHint string
+	AllowedTools []string
+	Body         string
+	Path         string
+}
+
+// Dirs returns the command directories in increasing order of precedence:
+// the user-level directory first, then the project one.
+func Dirs(cwd string) []string {
+	dirs := []string{}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".config", "lit", "commands"))
+	}
+	return append(dirs, filepath.Join(cwd, ".lit", "commands"))
+}
+
+// Load discovers the templates in dirs. Files in subdirectories are named
+// with a colon, so commands/git/review.md becomes /git:review. Later
+// directories override earlier ones.
+func Load(dirs []string) ([]Template, error) {
+	byName := map[string]Template{}
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+			name = strings.ReplaceAll(name, "/", ":")
+
+			template, err := parseFile(name, path)
+			if err != nil {
+				return err
+			}
+			byName[name] = template
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load commands from %s: %w", dir, err)
+		}
+	}
+
+	templates := make([]Template, 0, len(byName))
+	for _, template := range byName {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+func parseFile(name, path string) (Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Template{}, err
+	}
+
+	template := Template{Name: name, Path: path}
+	body := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	if rest, ok := strings.CutPrefix(body, "---\n"); ok {
+		if frontMatter, after, found := strings.Cut(rest, "\n---"); found {
+			body = strings.TrimPrefix(after, "\n")
+			for _, line := range strings.Split(frontMatter, "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if !ok {
+					continue
+				}
+				value = strings.Trim(strings.TrimSpace(value), `"'`)
+				switch strings.TrimSpace(strings.ToLower(key)) {
+				case "description":
+					template.Description = value
+				case "argument-hint":
+					template.ArgumentHint = value
+				case "allowed-tools":
+					template.AllowedTools = parseList(value)
+				}
+			}
+		}
+	}
+
+	template.Body = strings.TrimSpace(body)
+	if template.Description == "" {
+		firstLine, _, _ := strings.Cut(template.Body, "\n")
+		template.Description = strings.TrimSpace(strings.TrimLeft(firstLine, "# "))
+	}
+
+	return template, nil
+}
+
+func parseList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+var placeholderRe = regexp.MustCompile(`\$(ARGUMENTS|[1-9])`)
+
+// Expand substitutes $ARGUMENTS with the raw arguments and $1..$9 with the
+// positional ones. Arguments are appended when the template uses neither.
+func (t Template) Expand(args string) string {
+	positional := splitArgs(args)
+	used := false
+
+	expanded := placeholderRe.ReplaceAllStringFunc(t.Body, func(match string) string {
+		used = true
+		if match == "$ARGUMENTS" {
+			return args
+		}
+		index, _ := strconv.Atoi(match[1:])
+		if index <= len(positional) {
+			return positional[index-1]
+		}
+		return ""
+	})
+
+	if !used && strings.TrimSpace(args) != "" {
+		expanded += "\n\n" + args
+	}
+	return expanded
+}
+
+// splitArgs splits arguments on whitespace, keeping quoted strings together.
+func splitArgs(args string) []string {
+	result := []string{}
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range args {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				result = append(result, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		result = append(result, current.String())
+	}
+
+	return result
+}
@@ -9,10 +9,50 @@ import (
 )
 
 type Config struct {
-	Provider string `toml:"provider"`
-	Model    string `toml:"model"`
-	Anthropic AnthropicConfig `toml:"anthropic"`
-	OpenAI    OpenAIConfig    `toml:"openai"`
+	Provider         string            `toml:"provider"`
+	Model            string            `toml:"model"`
+	SystemPrompt     string            `toml:"system_prompt"`
+	SystemPromptFile string            `toml:"system_prompt_file"`
+	Anthropic        AnthropicConfig   `toml:"anthropic"`
+	OpenAI           OpenAIConfig      `toml:"openai"`
+	Context          ContextConfig     `toml:"context"`
+	Permissions      PermissionsConfig `toml:"permissions"`
+	Tools            ToolsConfig       `toml:"tools"`
+}
+
+// ToolsConfig holds tool timeouts in seconds. Timeouts overrides Timeout
+// for individual tools.
+type ToolsConfig struct {
+	Timeout  int            `toml:"timeout"`
+	Timeouts map[string]int `toml:"timeouts"`
+	Bash     BashConfig     `toml:"bash"`
+}
+
+// BashConfig sets up the bash tool sandbox: "off", "auto" (use bubblewrap
+// when it is installed) or "on" (refuse to run commands without it).
+// Writable lists paths besides the project that sandboxed commands may
+// write to, such as build caches.
+type BashConfig struct {
+	Sandbox  string   `toml:"sandbox"`
+	Writable []string `toml:"writable"`
+}
+
+// PermissionsConfig holds tool permission rules such as "read_file",
+// "edit_file(src/**)" or "git_commit(--amend)".
+type PermissionsConfig struct {
+	Allow []string `toml:"allow"`
+	Ask   []string `toml:"ask"`
+	Deny  []string `toml:"deny"`
+}
+
+// ProjectSettings is stored in .lit/settings.toml of a project.
+type ProjectSettings struct {
+	Permissions PermissionsConfig `toml:"permissions"`
+}
+
+type ContextConfig struct {
+	Window           int     `toml:"window"`
+	CompactThreshold float64 `toml:"compact_threshold"`
 }
 
 type AnthropicConfig struct {
@@ -20,7 +60,7 @@ type AnthropicConfig struct {
 }
 
 type OpenAIConfig struct {
-	APIKey string `toml:"api_key"`
+	APIKey  string `toml:"api_key"`
 	BaseURL string `toml:"base_url,omitempty"`
 }
 
@@ -35,6 +75,12 @@ func LoadConfig() (*Config, error) {
 	config := &Config{
 		Provider: "anthropic",
 		Model:    "claude-3-5-haiku-latest",
+		Context: ContextConfig{
+			CompactThreshold: 0.8,
+		},
+		Tools: ToolsConfig{
+			Timeout: 120,
+		},
 	}
 
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -70,6 +116,65 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("unsupported provider: %s (supported: anthropic, openai)", config.Provider)
 	}
 
+	if config.Tools.Timeout < 0 {
+		return fmt.Errorf("tools.timeout must not be negative")
+	}
+	for name, timeout := range config.Tools.Timeouts {
+		if timeout < 0 {
+			return fmt.Errorf("tools.timeouts.%s must not be negative", name)
+		}
+	}
+	switch config.Tools.Bash.Sandbox {
+	case "", "off", "auto", "on":
+	default:
+		return fmt.Errorf("invalid tools.bash.sandbox: %s (supported: off, auto, on)", config.Tools.Bash.Sandbox)
+	}
+
+	if config.Context.Window < 0 {
+		return fmt.Errorf("context.window must not be negative")
+	}
+	if config.Context.CompactThreshold <= 0 || config.Context.CompactThreshold > 1 {
+		return fmt.Errorf("context.compact_threshold must be between 0 and 1")
+	}
+
+	return nil
+}
+
+func ProjectSettingsPath(cwd string) string {
+	return filepath.Join(cwd, ".lit", "settings.toml")
+}
+
+func LoadProjectSettings(cwd string) (*ProjectSettings, error) {
+	settings := &ProjectSettings{}
+
+	path := ProjectSettingsPath(cwd)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return settings, nil
+	}
+
+	if _, err := toml.DecodeFile(path, settings); err != nil {
+		return nil, fmt.Errorf("failed to decode project settings %s: %w", path, err)
+	}
+
+	return settings, nil
+}
+
+func SaveProjectSettings(cwd string, settings *ProjectSettings) error {
+	path := ProjectSettingsPath(cwd)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create project settings directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write project settings: %w", err)
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(settings); err != nil {
+		return fmt.Errorf("failed to write project settings: %w", err)
+	}
+
 	return nil
 }
 
@@ -98,6 +203,11 @@ provider = "anthropic"
 # OpenAI: "gpt-4o", "gpt-4o-mini", "gpt-4-turbo", "gpt-3.5-turbo"
 model = "claude-3-5-haiku-latest"
 
+# Replace the built-in system prompt, inline or from a file.
+# Project instructions from LIT.md / AGENTS.md are still appended.
+# system_prompt = "You are a careful senior Go engineer."
+# system_prompt_file = "~/.config/lit/system.md"
+
 [anthropic]
 # API key (can also be set via ANTHROPIC_API_KEY environment variable)
 # api_key = "your-anthropic-api-key"
@@ -107,6 +217,41 @@ model = "claude-3-5-haiku-latest"
 # api_key = "your-openai-api-key"
 # Optional: Custom base URL for OpenAI-compatible APIs
 # base_url = "https://api.openai.com/v1"
+
+[context]
+# Context window in tokens. Defaults to the known size of the selected model.
+# window = 200000
+# Compact the conversation once it fills this fraction of the context window
+compact_threshold = 0.8
+
+[permissions]
+# Rules are a tool name, optionally with a glob for its path arguments, a
+# --flag for its boolean options or -- for calls that set none. A call that
+# sets an option is only allowed by a rule naming it or a bare tool name.
+# Deny wins over ask, ask wins over allow.
+# Read-only tools are allowed and everything else asks when no rule matches.
+# allow = ["edit_file(src/**)", "git_add"]
+# ask = ["git_commit(--amend)"]
+# deny = ["rm(/etc/**)", "rm(**/.git)"]
+# Commands of the bash tool are matched as a whole, "*" matches any text.
+# allow = ["bash(go test *)", "bash(make)"]
+
+[tools]
+# Default timeout for a tool call in seconds (0 disables it)
+timeout = 120
+
+[tools.timeouts]
+# Per-tool overrides in seconds
+# ripgrep = 30
+# git_commit = 300
+# bash = 600
+
+[tools.bash]
+# Run commands under bubblewrap on Linux, without network and only able to
+# write to the project: "off", "auto" (when bwrap is installed) or "on"
+sandbox = "off"
+# Extra writable paths inside the sandbox
+# writable = ["~/.cache/go-build"]
 `
 
 	if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
@@ -114,4 +259,4 @@ model = "claude-3-5-haiku-latest"
 	}
 
 	return nil
-}
\ No newline at end of file
+}
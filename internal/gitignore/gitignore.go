@@ -0,0 +1,365 @@
+// Package gitignore decides whether paths are ignored by git, following the
+// rules of gitignore(5): .gitignore files in every directory,
+// .git/info/exclude and the global core.excludesFile.
+package gitignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type pattern struct {
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (p pattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		rel = rel[strings.LastIndex(rel, "/")+1:]
+	}
+	return p.re.MatchString(rel)
+}
+
+// Matcher answers ignore queries for paths under a directory. Ignore files
+// are read lazily the first time a path below them is checked, so creating
+// a Matcher is cheap. It is safe for concurrent use.
+type Matcher struct {
+	base   string
+	root   string
+	gitDir string
+
+	once     sync.Once
+	excludes []pattern
+
+	mu   sync.Mutex
+	dirs map[string][]pattern
+}
+
+// New returns a Matcher for paths relative to dir. When dir is inside a git
+// repository the repository root is used, so .gitignore files above dir
+// apply too.
+func New(dir string) *Matcher {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		base = filepath.Clean(dir)
+	}
+
+	m := &Matcher{base: base, root: base, dirs: map[string][]pattern{}}
+	if root, gitDir, ok := findRepository(base); ok {
+		m.root, m.gitDir = root, gitDir
+	}
+	return m
+}
+
+// Ignored reports whether path, relative to the Matcher's directory or
+// absolute, is ignored. A path inside an ignored directory is ignored even
+// if a later pattern negates it, as in git. The .git directory is always
+// ignored; paths outside the repository never are.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.base, path)
+	}
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if parts[i] == ".git" {
+			return true
+		}
+		if m.match(parts[:i+1], i < len(parts)-1 || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match applies every pattern that can affect parts, from the lowest
+// precedence (global excludes) to the highest (the closest .gitignore); the
+// last matching pattern decides.
+func (m *Matcher) match(parts []string, isDir bool) bool {
+	m.once.Do(m.loadExcludes)
+
+	ignored := false
+	apply := func(patterns []pattern, rel string) {
+		for _, p := range patterns {
+			if p.matches(rel, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+
+	apply(m.excludes, strings.Join(parts, "/"))
+	for depth := 0; depth < len(parts); depth++ {
+		apply(m.patterns(strings.Join(parts[:depth], "/")), strings.Join(parts[depth:], "/"))
+	}
+	return ignored
+}
+
+func (m *Matcher) patterns(dir string) []pattern {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	patterns, ok := m.dirs[dir]
+	if !ok {
+		patterns = loadFile(filepath.Join(m.root, filepath.FromSlash(dir), ".gitignore"))
+		m.dirs[dir] = patterns
+	}
+	return patterns
+}
+
+func (m *Matcher) loadExcludes() {
+	if path := globalExcludesFile(); path != "" {
+		m.excludes = append(m.excludes, loadFile(path)...)
+	}
+	if m.gitDir != "" {
+		m.excludes = append(m.excludes, loadFile(filepath.Join(m.gitDir, "info", "exclude"))...)
+	}
+}
+
+func loadFile(path string) []pattern {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	patterns := []pattern{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := parsePattern(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+func parsePattern(line string) (pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	p.anchored = strings.Contains(line, "/")
+	re, err := compileGlob(strings.TrimPrefix(line, "/"))
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// compileGlob turns a gitignore glob into a regexp. "*", "?" and bracket
+// expressions never match a slash; "**" as a whole path segment matches any
+// number of directories.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch ch := glob[i]; ch {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				end := i + 2
+				if (i == 0 || glob[i-1] == '/') && (end == len(glob) || glob[end] == '/') {
+					if end == len(glob) {
+						sb.WriteString(".*")
+					} else {
+						sb.WriteString("(?:.*/)?")
+					}
+					i = end
+					continue
+				}
+				i++
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			class, next, ok := bracketExpression(glob, i)
+			if !ok {
+				sb.WriteString(`\[`)
+				continue
+			}
+			sb.WriteString(class)
+			i = next
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// bracketExpression converts the "[...]" starting at glob[start] and returns
+// the index of its closing bracket.
+func bracketExpression(glob string, start int) (string, int, bool) {
+	i := start + 1
+	negate := false
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		negate = true
+		i++
+	}
+	first := i
+	if i < len(glob) && glob[i] == ']' {
+		i++
+	}
+	end := strings.IndexByte(glob[i:], ']')
+	if end < 0 {
+		return "", 0, false
+	}
+	end += i
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	if negate {
+		sb.WriteString("^/")
+	}
+	for j := first; j < end; j++ {
+		switch ch := glob[j]; ch {
+		case '\\':
+			if j+1 < end {
+				j++
+			}
+			if c := glob[j]; c < 0x80 && !isAlphanumeric(c) {
+				sb.WriteString(`\` + string(c))
+			} else {
+				sb.WriteByte(c)
+			}
+		case '[', ']', '^':
+			sb.WriteString(`\` + string(ch))
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	sb.WriteString("]")
+	return sb.String(), end, true
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// findRepository walks up from dir to the directory containing .git and
+// returns it with the git directory, which .git points to in worktrees and
+// submodules.
+func findRepository(dir string) (string, string, bool) {
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dir, dotGit, true
+			}
+			if content, err := os.ReadFile(dotGit); err == nil {
+				if gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: "); ok {
+					if !filepath.IsAbs(gitDir) {
+						gitDir = filepath.Join(dir, gitDir)
+					}
+					return dir, gitDir, true
+				}
+			}
+			return dir, "", true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+// globalExcludesFile returns core.excludesFile from the user's git config,
+// or git's default of $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	// ~/.gitconfig is read after the XDG file, so its value wins.
+	for _, config := range []string{filepath.Join(home, ".gitconfig"), filepath.Join(configHome, "git", "config")} {
+		if path := readExcludesFile(config); path != "" {
+			if rest, ok := strings.CutPrefix(path, "~/"); ok && home != "" {
+				path = filepath.Join(home, rest)
+			}
+			return path
+		}
+	}
+
+	if configHome == "" {
+		return ""
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
+
+func readExcludesFile(configPath string) string {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	section := ""
+	value := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = ""
+			if fields := strings.Fields(strings.Trim(line, "[]")); len(fields) > 0 {
+				section = strings.ToLower(strings.Trim(fields[0], `"`))
+			}
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if ok && section == "core" && strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			value = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return value
+}
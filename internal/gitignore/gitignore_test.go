@@ -0,0 +1,191 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files under root from a map of slash-separated paths to
+// contents.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// isolateHome points the global git config at an empty home directory.
+func isolateHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home
+}
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"basename at any depth", map[string]string{".gitignore": "*.log\n"}, "a/b/debug.log", false, true},
+		{"no match", map[string]string{".gitignore": "*.log\n"}, "main.go", false, false},
+		{"comment and blank lines", map[string]string{".gitignore": "# *.go\n\n"}, "main.go", false, false},
+		{"escaped hash", map[string]string{".gitignore": "\\#notes\n"}, "#notes", false, true},
+		{"trailing spaces trimmed", map[string]string{".gitignore": "tmp   \n"}, "tmp", false, true},
+		{"escaped trailing space kept", map[string]string{".gitignore": "tmp\\ \n"}, "tmp ", false, true},
+
+		{"negation re-includes", map[string]string{".gitignore": "*.log\n!keep.log\n"}, "keep.log", false, false},
+		{"later pattern wins", map[string]string{".gitignore": "!keep.log\n*.log\n"}, "keep.log", false, true},
+		{"escaped bang is literal", map[string]string{".gitignore": "\\!important\n"}, "!important", false, true},
+		{"negation can't reach into ignored dir", map[string]string{".gitignore": "build/\n!build/keep.txt\n"}, "build/keep.txt", false, true},
+		{"re-include dir contents via star", map[string]string{".gitignore": "build/*\n!build/keep.txt\n"}, "build/keep.txt", false, false},
+
+		{"leading slash anchors", map[string]string{".gitignore": "/todo\n"}, "todo", false, true},
+		{"leading slash anchors, nested not matched", map[string]string{".gitignore": "/todo\n"}, "src/todo", false, false},
+		{"middle slash anchors", map[string]string{".gitignore": "doc/frotz\n"}, "doc/frotz", false, true},
+		{"middle slash anchors, nested not matched", map[string]string{".gitignore": "doc/frotz\n"}, "a/doc/frotz", false, false},
+		{"star stays in segment", map[string]string{".gitignore": "src/*.go\n"}, "src/a/b.go", false, false},
+
+		{"leading double star", map[string]string{".gitignore": "**/foo\n"}, "a/b/foo", false, true},
+		{"leading double star at root", map[string]string{".gitignore": "**/foo\n"}, "foo", false, true},
+		{"trailing double star", map[string]string{".gitignore": "abc/**\n"}, "abc/x/y", false, true},
+		{"trailing double star excludes dir itself", map[string]string{".gitignore": "abc/**\n"}, "abc", true, false},
+		{"middle double star zero dirs", map[string]string{".gitignore": "a/**/b\n"}, "a/b", false, true},
+		{"middle double star many dirs", map[string]string{".gitignore": "a/**/b\n"}, "a/x/y/b", false, true},
+		{"double star inside segment is a star", map[string]string{".gitignore": "a**b\n"}, "axyb", false, true},
+
+		{"dir-only matches dir", map[string]string{".gitignore": "logs/\n"}, "logs", true, true},
+		{"dir-only skips file", map[string]string{".gitignore": "logs/\n"}, "logs", false, false},
+		{"dir-only ignores contents", map[string]string{".gitignore": "logs/\n"}, "logs/today.txt", false, true},
+
+		{"bracket range", map[string]string{".gitignore": "[a-c].x\n"}, "b.x", false, true},
+		{"bracket range miss", map[string]string{".gitignore": "[a-c].x\n"}, "d.x", false, false},
+		{"negated bracket", map[string]string{".gitignore": "[!q].y\n"}, "q.y", false, false},
+		{"escaped letter in bracket is literal", map[string]string{".gitignore": "[\\d]\n"}, "d", false, true},
+		{"escaped letter in bracket is not a class", map[string]string{".gitignore": "[\\d]\n"}, "1", false, false},
+		{"escaped dash in bracket", map[string]string{".gitignore": "[a\\-c]\n"}, "b", false, false},
+		{"unclosed bracket is literal", map[string]string{".gitignore": "[abc\n"}, "[abc", false, true},
+
+		{"nested gitignore applies below it", map[string]string{"sub/.gitignore": "local\n"}, "sub/deep/local", false, true},
+		{"nested gitignore doesn't apply above it", map[string]string{"sub/.gitignore": "local\n"}, "local", false, false},
+		{"nested anchored is relative to its dir", map[string]string{"sub/.gitignore": "/only\n"}, "sub/only", false, true},
+		{"nested anchored not deeper", map[string]string{"sub/.gitignore": "/only\n"}, "sub/x/only", false, false},
+		{"nested negation overrides parent", map[string]string{".gitignore": "*.log\n", "sub/.gitignore": "!*.log\n"}, "sub/a.log", false, false},
+
+		{"info exclude", map[string]string{".git/info/exclude": "secret\n"}, "secret", false, true},
+		{"gitignore overrides info exclude", map[string]string{".git/info/exclude": "secret\n", ".gitignore": "!secret\n"}, "secret", false, false},
+		{"git dir always ignored", nil, ".git", true, true},
+		{"outside the repository", map[string]string{".gitignore": "*\n"}, "../elsewhere", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateHome(t)
+			root := t.TempDir()
+			if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, root, tt.files)
+
+			if got := New(root).Ignored(tt.path, tt.isDir); got != tt.ignored {
+				t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestIgnoredFromSubdirectory(t *testing.T) {
+	isolateHome(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":      "",
+		".gitignore":     "*.tmp\n",
+		"sub/.gitignore": "generated/\n",
+	})
+
+	m := New(filepath.Join(root, "sub"))
+	if !m.Ignored("a.tmp", false) {
+		t.Error("root .gitignore should apply to paths relative to a subdirectory")
+	}
+	if !m.Ignored("generated", true) {
+		t.Error("nested .gitignore should apply to paths relative to its directory")
+	}
+	if !m.Ignored(filepath.Join(root, "x.tmp"), false) {
+		t.Error("absolute paths should be resolved against the repository root")
+	}
+}
+
+func TestGlobalExcludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		path    string
+		ignored bool
+	}{
+		{"default XDG ignore file", map[string]string{".config/git/ignore": "*.swp\n"}, "a.swp", true},
+		{"excludesFile in gitconfig", map[string]string{".gitconfig": "[core]\n\texcludesFile = ~/global-ignore\n", "global-ignore": "*.bak\n"}, "a.bak", true},
+		{"excludesFile replaces default", map[string]string{".gitconfig": "[core]\n\texcludesfile = ~/global-ignore\n", "global-ignore": "", ".config/git/ignore": "*.swp\n"}, "a.swp", false},
+		{"gitconfig wins over XDG config", map[string]string{
+			".gitconfig":         "[core]\nexcludesFile = ~/home-ignore\n",
+			".config/git/config": "[core]\nexcludesFile = ~/xdg-ignore\n",
+			"home-ignore":        "*.home\n",
+			"xdg-ignore":         "*.xdg\n",
+		}, "a.xdg", false},
+		{"XDG config used alone", map[string]string{".config/git/config": "[core]\nexcludesFile = ~/xdg-ignore\n", "xdg-ignore": "*.xdg\n"}, "a.xdg", true},
+		{"repository ignores override global", map[string]string{".config/git/ignore": "*.swp\n", "repo/.gitignore": "!keep.swp\n"}, "keep.swp", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := isolateHome(t)
+			writeFiles(t, home, tt.files)
+			root := filepath.Join(home, "repo")
+			writeFiles(t, root, map[string]string{".git/HEAD": ""})
+
+			if got := New(root).Ignored(tt.path, false); got != tt.ignored {
+				t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestReadExcludesFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"core section", "[core]\n\texcludesFile = /etc/ignore\n", "/etc/ignore"},
+		{"key is case-insensitive", "[Core]\n\tEXCLUDESFILE=/etc/ignore\n", "/etc/ignore"},
+		{"quoted value", "[core]\nexcludesfile = \"/path with space\"\n", "/path with space"},
+		{"last value wins", "[core]\nexcludesfile = /a\n[core]\nexcludesfile = /b\n", "/b"},
+		{"other section ignored", "[user]\nexcludesfile = /a\n", ""},
+		{"subsection", "[remote \"origin\"]\nexcludesfile = /a\n", ""},
+		{"comments", "[core]\n# excludesfile = /a\n; excludesfile = /b\n", ""},
+		{"empty section header", "[]\nexcludesfile = /a\n", ""},
+		{"blank section header", "[ ]\n[core]\nexcludesfile = /a\n", "/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := readExcludesFile(path); got != tt.want {
+				t.Errorf("readExcludesFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadAnswer when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted by the user")
+
+// ReadAnswer reads the answer to a confirmation prompt. On a terminal it
+// reads a single key press, so nothing typed afterwards is consumed, and
+// Ctrl-C returns ErrInterrupted; Enter, Escape and Ctrl-D answer "".
+// Otherwise it reads one line through ReadStdinLine and gives up when ctx
+// is done.
+func ReadAnswer(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readAnswerLine(ctx)
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, oldState)
+
+	for {
+		var buf [4]byte
+		n, err := os.Stdin.Read(buf[:])
+		if err != nil {
+			return "", err
+		}
+
+		key := buf[:n]
+		switch {
+		case isCtrlC(key):
+			fmt.Fprint(os.Stderr, "^C\r\n")
+			return "", ErrInterrupted
+		case isEnter(key), isEscape(key), isCtrlD(key):
+			fmt.Fprint(os.Stderr, "\r\n")
+			return "", nil
+		case n == 1 && unicode.IsPrint(rune(key[0])):
+			answer := strings.ToLower(string(key))
+			fmt.Fprint(os.Stderr, answer+"\r\n")
+			return answer, nil
+		}
+	}
+}
+
+func readAnswerLine(ctx context.Context) (string, error) {
+	line, err := ReadStdinLine(ctx)
+	return strings.ToLower(strings.TrimSpace(line)), err
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/carlosarraes/lit/internal/gitignore"
 	"golang.org/x/term"
 )
 
@@ -27,6 +28,7 @@ type InteractiveInput struct {
 	lastCtrlC    time.Time
 	multiLine    []string
 	inContinuation bool
+	commands     []string
 }
 
 func NewInteractiveInput() *InteractiveInput {
@@ -156,6 +158,17 @@ func (i *InteractiveInput) handleTab() {
 			break
 		}
 	}
+
+	isCommand := false
+	if atPos == -1 && len(i.currentInput) > 0 && i.currentInput[0] == '/' {
+		isCommand = true
+		atPos = 0
+		for j := 0; j < i.cursorPos; j++ {
+			if unicode.IsSpace(i.currentInput[j]) {
+				return
+			}
+		}
+	}
 	
 	if atPos == -1 {
 		return
@@ -170,21 +183,43 @@ func (i *InteractiveInput) handleTab() {
 		for idx, suggestion := range i.suggestions {
 			if currentAfterAt == suggestion {
 				i.selectedIdx = (idx + 1) % len(i.suggestions)
-				i.applySuggestion()
+				i.applySuggestion(atPos)
 				return
 			}
 		}
 	}
 	
-	suggestions := getFileSuggestions(currentAfterAt)
+	var suggestions []string
+	if isCommand {
+		suggestions = i.getCommandSuggestions(currentAfterAt)
+	} else {
+		suggestions = getFileSuggestions(currentAfterAt)
+	}
 	
 	if len(suggestions) > 0 {
 		i.suggestions = suggestions
 		i.selectedIdx = 0
 		i.lastAtPos = atPos
 		i.lastPartial = currentAfterAt
-		i.applySuggestion()
+		i.applySuggestion(atPos)
+	}
+}
+
+// SetCommands sets the slash command names offered by Tab completion when
+// the line starts with '/'.
+func (i *InteractiveInput) SetCommands(names []string) {
+	i.commands = names
+}
+
+func (i *InteractiveInput) getCommandSuggestions(partial string) []string {
+	var suggestions []string
+	lowerPartial := strings.ToLower(partial)
+	for _, name := range i.commands {
+		if strings.HasPrefix(strings.ToLower(name), lowerPartial) {
+			suggestions = append(suggestions, name)
+		}
 	}
+	return suggestions
 }
 
 func (i *InteractiveInput) handleArrowNavigation(up bool) {
@@ -248,23 +283,11 @@ func (i *InteractiveInput) redrawLine() {
 	}
 }
 
-func (i *InteractiveInput) applySuggestion() {
+func (i *InteractiveInput) applySuggestion(atPos int) {
 	if i.selectedIdx < 0 || i.selectedIdx >= len(i.suggestions) {
 		return
 	}
 
-	atPos := -1
-	for j := i.cursorPos - 1; j >= 0; j-- {
-		if i.currentInput[j] == '@' {
-			atPos = j
-			break
-		}
-	}
-
-	if atPos == -1 {
-		return
-	}
-
 	suggestion := i.suggestions[i.selectedIdx]
 	
 	restOfLine := []rune{}
@@ -276,7 +299,7 @@ func (i *InteractiveInput) applySuggestion() {
 		}
 	}
 	if spacePos != -1 {
-		restOfLine = i.currentInput[spacePos:]
+		restOfLine = append(restOfLine, i.currentInput[spacePos:]...)
 	}
 	
 	newInput := append(i.currentInput[:atPos+1], []rune(suggestion)...)
@@ -395,10 +418,10 @@ func (i *InteractiveInput) checkLineContinuation() bool {
 	return false
 }
 
-var gitignoreChecker *GitignoreChecker
+var gitignoreMatcher *gitignore.Matcher
 
 func init() {
-	gitignoreChecker = NewGitignoreChecker(".")
+	gitignoreMatcher = gitignore.New(".")
 }
 
 func getFileSuggestions(partial string) []string {
@@ -437,7 +460,7 @@ func getFileSuggestions(partial string) []string {
 			checkPath = filepath.Join(dir, name)
 		}
 		
-		if gitignoreChecker.ShouldIgnore(checkPath) {
+		if gitignoreMatcher.Ignored(checkPath, entry.IsDir()) {
 			continue
 		}
 		
@@ -475,7 +498,7 @@ func getDirectoryContents(dir string) []string {
 		
 		checkPath := filepath.Join(dir, name)
 		
-		if gitignoreChecker.ShouldIgnore(checkPath) {
+		if gitignoreMatcher.Ignored(checkPath, entry.IsDir()) {
 			continue
 		}
 		
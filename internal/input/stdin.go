@@ -0,0 +1,54 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A stdin that is not a terminal is read by a single goroutine that hands
+// out one line at a time. A caller that stops waiting, like a confirmation
+// cancelled with Ctrl-C, leaves the line for the next caller instead of a
+// stray reader swallowing it.
+var (
+	stdinOnce  sync.Once
+	stdinLines chan string
+	stdinErr   error
+)
+
+func startStdinReader() {
+	stdinLines = make(chan string)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				stdinLines <- strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			}
+			if err != nil {
+				stdinErr = err
+				close(stdinLines)
+				return
+			}
+		}
+	}()
+}
+
+// ReadStdinLine returns the next line of stdin without its line ending. It
+// returns ctx's error when ctx is done first, and the read error, usually
+// io.EOF, once stdin is exhausted.
+func ReadStdinLine(ctx context.Context) (string, error) {
+	stdinOnce.Do(startStdinReader)
+
+	select {
+	case line, ok := <-stdinLines:
+		if !ok {
+			return "", stdinErr
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,528 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/carlosarraes/lit/internal/config"
+	"github.com/carlosarraes/lit/internal/glob"
+	"github.com/carlosarraes/lit/internal/input"
+)
+
+type Decision int
+
+const (
+	Allow Decision = iota
+	Ask
+	Deny
+)
+
+// Mode decides how Ask decisions are answered.
+type Mode int
+
+const (
+	ModeAsk Mode = iota
+	ModeApprove
+	ModeDeny
+)
+
+// pathKeys are the input fields matched against rule patterns.
+var pathKeys = []string{"path", "paths", "source", "destination"}
+
+// commandKeys are the input fields holding shell commands, which rule
+// patterns match as a whole with "*" matching any text.
+var commandKeys = []string{"command"}
+
+// noFlags is the pattern of a rule that covers calls setting no boolean
+// options.
+const noFlags = "--"
+
+// shellOperators chain, redirect or substitute commands.
+var shellOperators = []string{";", "&", "|", "\n", "`", "$(", ">", "<"}
+
+func ParseMode(value string) (Mode, error) {
+	switch strings.ToLower(value) {
+	case "", "ask":
+		return ModeAsk, nil
+	case "approve", "yes":
+		return ModeApprove, nil
+	case "deny", "no":
+		return ModeDeny, nil
+	default:
+		return ModeAsk, fmt.Errorf("invalid confirm mode: %s (supported: ask, approve, deny)", value)
+	}
+}
+
+type Rule struct {
+	Tool    string
+	Pattern string
+}
+
+// ParseRule parses "tool" or "tool(pattern)".
+func ParseRule(value string) (Rule, error) {
+	value = strings.TrimSpace(value)
+	tool, pattern, hasPattern := strings.Cut(value, "(")
+	if hasPattern {
+		if !strings.HasSuffix(pattern, ")") {
+			return Rule{}, fmt.Errorf("invalid permission rule %q: missing ')'", value)
+		}
+		pattern = strings.TrimSpace(strings.TrimSuffix(pattern, ")"))
+	}
+	tool = strings.TrimSpace(tool)
+	if tool == "" {
+		return Rule{}, fmt.Errorf("invalid permission rule %q: missing tool name", value)
+	}
+	return Rule{Tool: tool, Pattern: pattern}, nil
+}
+
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Tool
+	}
+	return fmt.Sprintf("%s(%s)", r.Tool, r.Pattern)
+}
+
+type Checker struct {
+	cwd  string
+	mode Mode
+
+	mu      sync.Mutex
+	allow   []Rule
+	ask     []Rule
+	deny    []Rule
+	granted []Rule
+}
+
+func NewChecker(cwd string, mode Mode, rules ...config.PermissionsConfig) (*Checker, error) {
+	c := &Checker{cwd: cwd, mode: mode}
+	for _, set := range rules {
+		for _, target := range []struct {
+			values []string
+			rules  *[]Rule
+		}{{set.Allow, &c.allow}, {set.Ask, &c.ask}, {set.Deny, &c.deny}} {
+			for _, value := range target.values {
+				rule, err := ParseRule(value)
+				if err != nil {
+					return nil, err
+				}
+				*target.rules = append(*target.rules, rule)
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Checker) SetMode(mode Mode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+}
+
+// Decide applies the rules without asking: deny rules win, then ask rules,
+// then allow rules together with anything granted during this session.
+// Read-only tools are allowed and everything else asks when no rule
+// matches. extraPaths are files the call touches beyond the input's path
+// fields.
+func (c *Checker) Decide(tool string, readOnly bool, input json.RawMessage, extraPaths ...string) (Decision, Rule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.callSubject(input, extraPaths)
+
+	for _, rule := range c.deny {
+		if c.matches(rule, tool, s) {
+			return Deny, rule
+		}
+	}
+	for _, rule := range c.ask {
+		if c.matches(rule, tool, s) {
+			return Ask, rule
+		}
+	}
+	allowed := append(append([]Rule{}, c.allow...), c.granted...)
+	if c.covers(allowed, tool, s) {
+		return Allow, Rule{Tool: tool}
+	}
+
+	if readOnly {
+		return Allow, Rule{Tool: tool}
+	}
+	return Ask, Rule{Tool: tool}
+}
+
+// Authorize decides whether the tool call may run, asking the user when
+// needed. The returned reason explains a refusal to the model. The error is
+// set when the user interrupted the question or ctx was cancelled while
+// waiting for an answer.
+func (c *Checker) Authorize(ctx context.Context, tool string, readOnly bool, toolInput json.RawMessage, extraPaths ...string) (bool, string, error) {
+	decision, rule := c.Decide(tool, readOnly, toolInput, extraPaths...)
+	switch decision {
+	case Allow:
+		return true, "", nil
+	case Deny:
+		return false, fmt.Sprintf("Permission denied: %s is blocked by the rule %q", tool, rule.String()), nil
+	}
+
+	c.mu.Lock()
+	mode := c.mode
+	c.mu.Unlock()
+
+	switch mode {
+	case ModeApprove:
+		return true, "", nil
+	case ModeDeny:
+		return false, fmt.Sprintf("Permission denied: %s requires confirmation and confirmations are disabled", tool), nil
+	}
+
+	rules := c.grantRules(tool, c.callSubject(toolInput, extraPaths))
+
+	answer, err := c.prompt(ctx, tool, toolInput, rules)
+	if errors.Is(err, input.ErrInterrupted) || ctx.Err() != nil {
+		return false, fmt.Sprintf("Permission denied: the user interrupted the confirmation for %s", tool), err
+	}
+	if err != nil {
+		return false, fmt.Sprintf("Permission denied: could not ask the user: %v", err), nil
+	}
+
+	switch answer {
+	case "y", "yes":
+		return true, "", nil
+	case "s", "session":
+		c.grant(rules...)
+		return true, "", nil
+	case "p", "project":
+		c.grant(rules...)
+		if err := c.saveProjectRules(rules); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save project permission: %v\n", err)
+		}
+		return true, "", nil
+	default:
+		return false, fmt.Sprintf("Permission denied: the user declined to run %s", tool), nil
+	}
+}
+
+// grantRules returns the rules that approve what the user was shown: each
+// path and exact command of the call and each boolean option it sets. A call
+// with none of these is granted "--", which only covers calls that set no
+// options, so approving a plain commit doesn't approve an amend.
+func (c *Checker) grantRules(tool string, s subject) []Rule {
+	rules := []Rule{}
+	for _, path := range s.paths {
+		rules = append(rules, Rule{Tool: tool, Pattern: c.relativePath(path)})
+	}
+	for _, command := range s.commands {
+		rules = append(rules, Rule{Tool: tool, Pattern: commandPattern(command)})
+	}
+	for _, flag := range sortedFlags(s.flags) {
+		rules = append(rules, Rule{Tool: tool, Pattern: "--" + flag})
+	}
+	if len(rules) == 0 {
+		rules = append(rules, Rule{Tool: tool, Pattern: noFlags})
+	}
+	return rules
+}
+
+// callSubject returns the subject of a call with every path made absolute
+// and cleaned, so "../" can't walk past a rule.
+func (c *Checker) callSubject(input json.RawMessage, extraPaths []string) subject {
+	s := subjects(input)
+	s.paths = append(s.paths, extraPaths...)
+	for i, path := range s.paths {
+		s.paths[i] = c.absolutePath(path)
+	}
+	return s
+}
+
+func (c *Checker) absolutePath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	if c.cwd == "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return filepath.Clean(path)
+	}
+	return filepath.Join(c.cwd, path)
+}
+
+// relativePath returns path relative to the working directory when it is
+// inside it, and absolute otherwise.
+func (c *Checker) relativePath(path string) string {
+	path = c.absolutePath(path)
+	if c.cwd != "" {
+		if rel, err := filepath.Rel(c.cwd, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+func sortedFlags(flags map[string]bool) []string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Checker) grant(rules ...Rule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.granted = append(c.granted, rules...)
+}
+
+func (c *Checker) prompt(ctx context.Context, tool string, toolInput json.RawMessage, rules []Rule) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	scope := make([]string, len(rules))
+	for i, rule := range rules {
+		scope[i] = rule.String()
+	}
+
+	fmt.Fprintf(os.Stderr, "\n⚠️  %s wants to run with %s\n", tool, toolInput)
+	fmt.Fprintf(os.Stderr, "Allow? [y] once, [s] %s for this session, [p] always in this project, [N] deny: ", strings.Join(scope, ", "))
+
+	return input.ReadAnswer(ctx)
+}
+
+func (c *Checker) saveProjectRules(rules []Rule) error {
+	settings, err := config.LoadProjectSettings(c.cwd)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, value := range settings.Permissions.Allow {
+		existing[value] = true
+	}
+	for _, rule := range rules {
+		if !existing[rule.String()] {
+			existing[rule.String()] = true
+			settings.Permissions.Allow = append(settings.Permissions.Allow, rule.String())
+		}
+	}
+	sort.Strings(settings.Permissions.Allow)
+
+	return config.SaveProjectSettings(c.cwd, settings)
+}
+
+// matches reports whether a deny or ask rule applies to the call. A rule
+// without pattern matches every call of its tool. A pattern starting with
+// "--" matches a boolean option that is set, and "--" alone a call that sets
+// none; any other pattern is a glob that needs to match one of the paths or
+// commands.
+func (c *Checker) matches(rule Rule, tool string, s subject) bool {
+	if rule.Tool != tool && rule.Tool != "*" {
+		return false
+	}
+	if isWildcard(rule.Pattern) {
+		return true
+	}
+	if rule.Pattern == noFlags {
+		return len(s.flags) == 0
+	}
+	if strings.HasPrefix(rule.Pattern, "--") {
+		return s.flags[strings.TrimPrefix(rule.Pattern, "--")]
+	}
+
+	for _, path := range s.paths {
+		if c.matchPath(rule.Pattern, path) {
+			return true
+		}
+	}
+	for _, command := range s.commands {
+		if matchCommand(rule.Pattern, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether allow rules together approve the call: each path
+// and command has to match one of the rules, and a command that chains
+// others has to be granted verbatim. Each boolean option the call sets needs
+// a rule naming it, so "git_commit" written in a config approves an amend
+// but a "git_commit(--)" or path rule doesn't. A call with none of these
+// needs a rule without pattern or "--".
+func (c *Checker) covers(rules []Rule, tool string, s subject) bool {
+	applicable := []Rule{}
+	for _, rule := range rules {
+		if rule.Tool == tool || rule.Tool == "*" {
+			applicable = append(applicable, rule)
+		}
+	}
+	covered := func(match func(pattern string) bool) bool {
+		for _, rule := range applicable {
+			if isWildcard(rule.Pattern) || match(rule.Pattern) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, path := range s.paths {
+		if !covered(func(pattern string) bool { return !isFlag(pattern) && c.matchPath(pattern, path) }) {
+			return false
+		}
+	}
+	for _, command := range s.commands {
+		if chainsCommands(command) {
+			exact := commandPattern(command)
+			if !slices.ContainsFunc(applicable, func(rule Rule) bool { return rule.Pattern == exact }) {
+				return false
+			}
+			continue
+		}
+		if !covered(func(pattern string) bool { return !isFlag(pattern) && matchCommand(pattern, command) }) {
+			return false
+		}
+	}
+	for flag := range s.flags {
+		if !covered(func(pattern string) bool { return pattern == "--"+flag }) {
+			return false
+		}
+	}
+
+	if len(s.paths) > 0 || len(s.commands) > 0 || len(s.flags) > 0 {
+		return true
+	}
+	return covered(func(pattern string) bool { return pattern == noFlags })
+}
+
+func isWildcard(pattern string) bool {
+	return pattern == "" || pattern == "*" || pattern == "**"
+}
+
+func isFlag(pattern string) bool {
+	return strings.HasPrefix(pattern, "--")
+}
+
+// matchPath matches pattern against the absolute form of path and, inside
+// the working directory, against the form relative to it.
+func (c *Checker) matchPath(pattern, path string) bool {
+	abs := filepath.ToSlash(c.absolutePath(path))
+	if glob.Match(pattern, abs) {
+		return true
+	}
+	rel := c.relativePath(path)
+	return rel != abs && glob.Match(pattern, rel)
+}
+
+// matchCommand matches a shell command against pattern, where "*" matches
+// any text and a backslash escapes the next character. For a command that
+// chains, redirects or substitutes others, any of its parts may match too.
+func matchCommand(pattern, command string) bool {
+	command = strings.TrimSpace(command)
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case ch == '*':
+			sb.WriteString(".*")
+		case ch == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return false
+	}
+
+	if re.MatchString(command) {
+		return true
+	}
+	if !chainsCommands(command) {
+		return false
+	}
+	parts := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune(";&|\n`()<>", r)
+	})
+	for _, part := range parts {
+		if re.MatchString(strings.TrimSpace(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// chainsCommands reports whether command runs, redirects or substitutes
+// more than a single command. Allow rules, including ones without pattern,
+// only approve such a command when it was granted verbatim, so
+// "bash(go test *)" can't approve "go test ./... && rm -rf ~".
+func chainsCommands(command string) bool {
+	for _, operator := range shellOperators {
+		if strings.Contains(command, operator) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandPattern returns a pattern that matches exactly command.
+func commandPattern(command string) string {
+	command = strings.TrimSpace(command)
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`).Replace(command)
+}
+
+// subject is what rule patterns are matched against in a tool input.
+type subject struct {
+	paths    []string
+	commands []string
+	flags    map[string]bool
+}
+
+// subjects extracts the path arguments, shell commands and enabled boolean
+// options from a tool input.
+func subjects(input json.RawMessage) subject {
+	fields := map[string]json.RawMessage{}
+	s := subject{flags: map[string]bool{}}
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return s
+	}
+
+	for _, key := range pathKeys {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		var single string
+		var multiple []string
+		if json.Unmarshal(raw, &single) == nil && single != "" {
+			s.paths = append(s.paths, single)
+		} else if json.Unmarshal(raw, &multiple) == nil {
+			s.paths = append(s.paths, multiple...)
+		}
+	}
+
+	for _, key := range commandKeys {
+		var command string
+		if raw, ok := fields[key]; ok && json.Unmarshal(raw, &command) == nil && command != "" {
+			s.commands = append(s.commands, command)
+		}
+	}
+
+	for key, raw := range fields {
+		var enabled bool
+		if json.Unmarshal(raw, &enabled) == nil && enabled {
+			s.flags[key] = true
+		}
+	}
+
+	return s
+}
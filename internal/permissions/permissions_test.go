@@ -0,0 +1,151 @@
+package permissions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/carlosarraes/lit/internal/config"
+)
+
+func newChecker(t *testing.T, cwd string, rules config.PermissionsConfig) *Checker {
+	t.Helper()
+	c, err := NewChecker(cwd, ModeAsk, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// grantCall records the rules a session answer to the call would grant.
+func grantCall(c *Checker, tool, input string) []Rule {
+	rules := c.grantRules(tool, c.callSubject(json.RawMessage(input), nil))
+	c.grant(rules...)
+	return rules
+}
+
+func TestGrantScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    string
+		granted string
+		call    string
+		want    Decision
+	}{
+		{"plain commit again", "git_commit", `{"message":"a"}`, `{"message":"b"}`, Allow},
+		{"plain commit doesn't approve amend", "git_commit", `{"message":"a"}`, `{"message":"b","amend":true}`, Ask},
+		{"amend approves amend", "git_commit", `{"message":"a","amend":true}`, `{"message":"b","amend":true}`, Allow},
+		{"amend doesn't approve a plain commit", "git_commit", `{"message":"a","amend":true}`, `{"message":"b"}`, Ask},
+		{"path", "rm", `{"path":"build"}`, `{"path":"build"}`, Allow},
+		{"path doesn't approve other paths", "rm", `{"path":"build"}`, `{"path":"src"}`, Ask},
+		{"path doesn't approve a flag", "rm", `{"path":"build"}`, `{"path":"build","recursive":true}`, Ask},
+		{"path and flag", "rm", `{"path":"build","recursive":true}`, `{"path":"build","recursive":true}`, Allow},
+		{"command", "bash", `{"command":"go test ./..."}`, `{"command":"go test ./..."}`, Allow},
+		{"command doesn't approve others", "bash", `{"command":"go test ./..."}`, `{"command":"go test ./... -run X"}`, Ask},
+		{"chained command verbatim", "bash", `{"command":"make && make test"}`, `{"command":"make && make test"}`, Allow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newChecker(t, t.TempDir(), config.PermissionsConfig{})
+			grantCall(c, tt.tool, tt.granted)
+			if got, _ := c.Decide(tt.tool, false, json.RawMessage(tt.call)); got != tt.want {
+				t.Errorf("Decide(%s) = %v, want %v", tt.call, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantRulesRoundTrip(t *testing.T) {
+	c := newChecker(t, t.TempDir(), config.PermissionsConfig{})
+	rules := grantCall(c, "git_commit", `{"message":"a"}`)
+	if len(rules) != 1 || rules[0].String() != "git_commit(--)" {
+		t.Fatalf("grantRules() = %v, want [git_commit(--)]", rules)
+	}
+
+	// A project answer is saved and loaded back as a config rule, which has
+	// to keep the same scope.
+	saved := newChecker(t, t.TempDir(), config.PermissionsConfig{Allow: []string{rules[0].String()}})
+	if got, _ := saved.Decide("git_commit", false, json.RawMessage(`{"message":"b"}`)); got != Allow {
+		t.Errorf("saved grant: plain commit = %v, want Allow", got)
+	}
+	if got, _ := saved.Decide("git_commit", false, json.RawMessage(`{"message":"b","amend":true}`)); got != Ask {
+		t.Errorf("saved grant: amend = %v, want Ask", got)
+	}
+}
+
+func TestConfigRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules config.PermissionsConfig
+		tool  string
+		call  string
+		want  Decision
+	}{
+		{"bare rule approves flags", config.PermissionsConfig{Allow: []string{"git_commit"}}, "git_commit", `{"amend":true}`, Allow},
+		{"bare rule approves a plain call", config.PermissionsConfig{Allow: []string{"git_commit"}}, "git_commit", `{"message":"a"}`, Allow},
+		{"flag rule", config.PermissionsConfig{Allow: []string{"git_add(--all)"}}, "git_add", `{"all":true}`, Allow},
+		{"ask rule wins over allow", config.PermissionsConfig{Allow: []string{"git_commit"}, Ask: []string{"git_commit(--amend)"}}, "git_commit", `{"amend":true}`, Ask},
+		{"no-flags ask rule", config.PermissionsConfig{Allow: []string{"git_commit"}, Ask: []string{"git_commit(--)"}}, "git_commit", `{"message":"a"}`, Ask},
+		{"no-flags ask rule skips flags", config.PermissionsConfig{Allow: []string{"git_commit"}, Ask: []string{"git_commit(--)"}}, "git_commit", `{"amend":true}`, Allow},
+		{"path rule and flag rule", config.PermissionsConfig{Allow: []string{"rm(build/**)", "rm(--recursive)"}}, "rm", `{"path":"build/out","recursive":true}`, Allow},
+		{"flag rule alone doesn't approve paths", config.PermissionsConfig{Allow: []string{"rm(--recursive)"}}, "rm", `{"path":"build","recursive":true}`, Ask},
+		{"allow rule never approves chained commands", config.PermissionsConfig{Allow: []string{"bash"}}, "bash", `{"command":"go test && rm -rf ~"}`, Ask},
+		{"deny wins", config.PermissionsConfig{Allow: []string{"rm"}, Deny: []string{"rm(/etc/**)"}}, "rm", `{"path":"/etc/passwd"}`, Deny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newChecker(t, t.TempDir(), tt.rules)
+			if got, _ := c.Decide(tt.tool, false, json.RawMessage(tt.call)); got != tt.want {
+				t.Errorf("Decide(%s) = %v, want %v", tt.call, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathResolution(t *testing.T) {
+	cwd := "/home/dev/project"
+	tests := []struct {
+		name  string
+		rules config.PermissionsConfig
+		tool  string
+		call  string
+		want  Decision
+	}{
+		{"absolute path denied", config.PermissionsConfig{Deny: []string{"rm(/etc/**)"}}, "rm", `{"path":"/etc/passwd"}`, Deny},
+		{"dot-dot can't escape a deny rule", config.PermissionsConfig{Deny: []string{"rm(/etc/**)"}}, "rm", `{"path":"../../../etc/passwd"}`, Deny},
+		{"dot-dot inside a path", config.PermissionsConfig{Deny: []string{"rm(/etc/**)"}}, "rm", `{"path":"src/../../../../etc/passwd"}`, Deny},
+		{"dot-dot in a move destination", config.PermissionsConfig{Allow: []string{"mv"}, Deny: []string{"mv(/etc/**)"}}, "mv", `{"source":"a","destination":"../../../etc/x"}`, Deny},
+		{"relative deny rule matches an absolute path", config.PermissionsConfig{Deny: []string{"rm(**/.git)"}}, "rm", `{"path":"/home/dev/project/.git"}`, Deny},
+		{"relative allow rule matches an absolute path", config.PermissionsConfig{Allow: []string{"edit_file(src/**)"}}, "edit_file", `{"path":"/home/dev/project/src/main.go"}`, Allow},
+		{"absolute allow rule matches a relative path", config.PermissionsConfig{Allow: []string{"edit_file(/home/dev/project/src/**)"}}, "edit_file", `{"path":"src/main.go"}`, Allow},
+		{"dot-dot can't reach an allow rule", config.PermissionsConfig{Allow: []string{"edit_file(src/**)"}}, "edit_file", `{"path":"src/../../other/src/main.go"}`, Ask},
+		{"cleaned before matching", config.PermissionsConfig{Allow: []string{"edit_file(src/**)"}}, "edit_file", `{"path":"./lib/../src/main.go"}`, Allow},
+		{"outside the project only the absolute path matches", config.PermissionsConfig{Allow: []string{"edit_file(../other/main.go)"}}, "edit_file", `{"path":"../other/main.go"}`, Ask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newChecker(t, cwd, tt.rules)
+			if got, _ := c.Decide(tt.tool, false, json.RawMessage(tt.call)); got != tt.want {
+				t.Errorf("Decide(%s) = %v, want %v", tt.call, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantedPathsAreResolved(t *testing.T) {
+	c := newChecker(t, "/home/dev/project", config.PermissionsConfig{})
+	rules := grantCall(c, "edit_file", `{"path":"./src/../src/main.go"}`)
+	if len(rules) != 1 || rules[0].String() != "edit_file(src/main.go)" {
+		t.Fatalf("grantRules() = %v, want [edit_file(src/main.go)]", rules)
+	}
+	if got, _ := c.Decide("edit_file", false, json.RawMessage(`{"path":"/home/dev/project/src/main.go"}`)); got != Allow {
+		t.Errorf("Decide(absolute) = %v, want Allow", got)
+	}
+
+	rules = grantCall(c, "rm", `{"path":"../secret"}`)
+	if len(rules) != 1 || rules[0].String() != "rm(/home/dev/secret)" {
+		t.Errorf("grantRules() = %v, want [rm(/home/dev/secret)]", rules)
+	}
+}
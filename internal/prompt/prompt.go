@@ -0,0 +1,171 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const maxInstructionFileSize = 64 * 1024
+
+var InstructionFiles = []string{"LIT.md", "AGENTS.md"}
+
+const defaultPrompt = `You are Lit, a coding agent running in the user's terminal. You help with software engineering tasks: reading and explaining code, finding things in the repository, making edits, running commands, and using git.
+
+Guidelines:
+- Use the available tools to look at the code instead of guessing. Search with ripgrep and fd before reading whole files.
+- Always read a file before editing it, and keep edits minimal and focused on the request.
+- Follow the conventions of the surrounding code: naming, error handling, formatting and comments.
+- After changing code, build and test it with the bash tool when the project has a way to.
+- Never commit, remove files or run destructive git operations unless the user asked for it.
+- Be concise. Explain what you changed and anything the user should verify.`
+
+type Options struct {
+	// Override replaces the built-in default prompt when set.
+	Override string
+	Cwd      string
+}
+
+// Build assembles the system prompt from the base prompt, a description of
+// the environment and any project or user instruction files.
+func Build(opts Options) (string, error) {
+	cwd := opts.Cwd
+	if cwd == "" {
+		var err error
+		cwd, err = os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+	}
+
+	base := defaultPrompt
+	if strings.TrimSpace(opts.Override) != "" {
+		base = strings.TrimSpace(opts.Override)
+	}
+
+	sections := []string{base, environment(cwd)}
+
+	for _, path := range instructionPaths(cwd) {
+		content, err := readInstructionFile(path)
+		if err != nil || content == "" {
+			continue
+		}
+		sections = append(sections, fmt.Sprintf("Instructions from %s:\n\n%s", path, content))
+	}
+
+	return strings.Join(sections, "\n\n"), nil
+}
+
+func LoadFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[2:])
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt file: %w", err)
+	}
+	return string(content), nil
+}
+
+func environment(cwd string) string {
+	lines := []string{
+		"Environment:",
+		"- Working directory: " + cwd,
+		fmt.Sprintf("- Platform: %s/%s", runtime.GOOS, runtime.GOARCH),
+		"- Date: " + time.Now().Format("2006-01-02"),
+	}
+
+	if root := gitRoot(cwd); root != "" {
+		lines = append(lines, "- Git repository: "+root)
+		if branch := gitBranch(cwd); branch != "" {
+			lines = append(lines, "- Git branch: "+branch)
+		}
+	} else {
+		lines = append(lines, "- Git repository: no")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// instructionPaths returns the user-level instruction files followed by the
+// project ones, ordered from the repository root down to cwd so that the
+// most specific instructions come last.
+func instructionPaths(cwd string) []string {
+	paths := []string{}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, existingInstructionFiles(filepath.Join(homeDir, ".config", "lit"))...)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+		cwd = resolved
+	}
+
+	root := gitRoot(cwd)
+	if root == "" {
+		root = cwd
+	}
+
+	dirs := []string{}
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+		if dir == root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	for _, dir := range dirs {
+		paths = append(paths, existingInstructionFiles(dir)...)
+	}
+
+	return paths
+}
+
+func existingInstructionFiles(dir string) []string {
+	paths := []string{}
+	for _, name := range InstructionFiles {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func readInstructionFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxInstructionFileSize {
+		content = append(content[:maxInstructionFileSize], []byte("\n... [truncated]")...)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func gitRoot(cwd string) string {
+	return gitOutput(cwd, "rev-parse", "--show-toplevel")
+}
+
+func gitBranch(cwd string) string {
+	return gitOutput(cwd, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+func gitOutput(cwd string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = cwd
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
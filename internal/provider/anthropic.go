@@ -33,19 +33,42 @@ func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
 }
 
 func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message, tools []tools.ToolDefinition) (*Response, error) {
+	return p.ChatStream(ctx, messages, tools, nil)
+}
+
+func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []Message, tools []tools.ToolDefinition, onEvent func(StreamEvent)) (*Response, error) {
+	emit := eventEmitter(onEvent)
 	anthropicMessages := make([]anthropic.MessageParam, 0, len(messages))
 
+	system := []anthropic.TextBlockParam{}
 	for _, msg := range messages {
+		if strings.ToLower(msg.Role) == "system" {
+			if text := msg.Text(); text != "" {
+				system = append(system, anthropic.TextBlockParam{Text: text})
+			}
+			continue
+		}
+
+		blocks := make([]anthropic.ContentBlockParamUnion, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			switch block.Type {
+			case BlockText:
+				blocks = append(blocks, anthropic.NewTextBlock(block.Text))
+			case BlockToolUse:
+				blocks = append(blocks, anthropic.NewToolUseBlock(block.ID, toolInput(block.Input), block.Name))
+			case BlockToolResult:
+				blocks = append(blocks, anthropic.NewToolResultBlock(block.ToolUseID, block.Content, block.IsError))
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
 		switch strings.ToLower(msg.Role) {
-		case "user":
-			userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content))
-			anthropicMessages = append(anthropicMessages, userMessage)
 		case "assistant":
-			assistantMessage := anthropic.NewAssistantMessage(anthropic.NewTextBlock(msg.Content))
-			anthropicMessages = append(anthropicMessages, assistantMessage)
+			anthropicMessages = append(anthropicMessages, anthropic.NewAssistantMessage(blocks...))
 		default:
-			userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content))
-			anthropicMessages = append(anthropicMessages, userMessage)
+			anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(blocks...))
 		}
 	}
 
@@ -60,24 +83,53 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message, tools
 		})
 	}
 
-	modelName := anthropic.ModelClaude3_5HaikuLatest
-	if p.model == "claude-3-5-sonnet-latest" {
-		modelName = anthropic.ModelClaude3_5SonnetLatest
-	} else if p.model == "claude-3-opus-latest" {
-		modelName = anthropic.ModelClaude3OpusLatest
-	}
-
-	response, err := p.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     modelName,
+	stream := p.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(p.model),
 		MaxTokens: 8192,
+		System:    system,
 		Messages:  anthropicMessages,
 		Tools:     anthropicTools,
 	})
-	if err != nil {
+	defer stream.Close()
+
+	response := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := response.Accumulate(event); err != nil {
+			return nil, err
+		}
+
+		switch event.Type {
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				emit(StreamEvent{
+					Type:  EventToolCallStart,
+					Index: int(event.Index),
+					ToolCall: &ToolCall{
+						ID:   event.ContentBlock.ID,
+						Name: event.ContentBlock.Name,
+					},
+				})
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				emit(StreamEvent{Type: EventTextDelta, Index: int(event.Index), Text: event.Delta.Text})
+			case "input_json_delta":
+				emit(StreamEvent{Type: EventToolCallDelta, Index: int(event.Index), Text: event.Delta.PartialJSON})
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
 		return nil, err
 	}
 
-	result := &Response{}
+	result := &Response{
+		Usage: Usage{
+			InputTokens:  int(response.Usage.InputTokens),
+			OutputTokens: int(response.Usage.OutputTokens),
+		},
+	}
 	toolCalls := make([]ToolCall, 0)
 
 	for _, content := range response.Content {
@@ -98,9 +150,14 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message, tools
 	}
 
 	result.ToolCalls = toolCalls
+	emit(StreamEvent{Type: EventUsage, Usage: &result.Usage})
 	return result, nil
 }
 
 func (p *AnthropicProvider) GetModel() string {
 	return p.model
+}
+
+func (p *AnthropicProvider) SetModel(model string) {
+	p.model = model
 }
\ No newline at end of file
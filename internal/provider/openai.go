@@ -3,7 +3,9 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/sashabaranov/go-openai"
@@ -36,27 +38,15 @@ func NewOpenAIProvider(apiKey, baseURL, model string) *OpenAIProvider {
 }
 
 func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, toolDefs []tools.ToolDefinition) (*Response, error) {
+	return p.ChatStream(ctx, messages, toolDefs, nil)
+}
+
+func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, toolDefs []tools.ToolDefinition, onEvent func(StreamEvent)) (*Response, error) {
+	emit := eventEmitter(onEvent)
 	openaiMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
 
 	for _, msg := range messages {
-		role := strings.ToLower(msg.Role)
-		switch role {
-		case "user":
-			openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
-				Role:    openai.ChatMessageRoleUser,
-				Content: msg.Content,
-			})
-		case "assistant":
-			openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
-				Role:    openai.ChatMessageRoleAssistant,
-				Content: msg.Content,
-			})
-		default:
-			openaiMessages = append(openaiMessages, openai.ChatCompletionMessage{
-				Role:    openai.ChatMessageRoleUser,
-				Content: msg.Content,
-			})
-		}
+		openaiMessages = append(openaiMessages, convertToOpenAIMessages(msg)...)
 	}
 
 	openaiTools := make([]openai.Tool, 0, len(toolDefs))
@@ -81,39 +71,103 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, toolDefs
 	}
 
 	request := openai.ChatCompletionRequest{
-		Model:    p.model,
-		Messages: openaiMessages,
-		Tools:    openaiTools,
+		Model:         p.model,
+		Messages:      openaiMessages,
+		Tools:         openaiTools,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
 	}
 
-	response, err := p.client.CreateChatCompletion(ctx, request)
+	stream, err := p.client.CreateChatCompletionStream(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+	defer stream.Close()
+
+	result := &Response{}
+	var content strings.Builder
+	toolCalls := []ToolCall{}
+	arguments := []string{}
+	received := false
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no response choices returned")
-	}
+		if chunk.Usage != nil {
+			result.Usage = Usage{
+				InputTokens:  chunk.Usage.PromptTokens,
+				OutputTokens: chunk.Usage.CompletionTokens,
+			}
+		}
 
-	choice := response.Choices[0]
-	result := &Response{
-		Content: choice.Message.Content,
-	}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		received = true
 
-	if len(choice.Message.ToolCalls) > 0 {
-		toolCalls := make([]ToolCall, 0, len(choice.Message.ToolCalls))
-		for _, tc := range choice.Message.ToolCalls {
-			if tc.Function.Arguments != "" {
-				toolCalls = append(toolCalls, ToolCall{
-					ID:    tc.ID,
-					Name:  tc.Function.Name,
-					Input: json.RawMessage(tc.Function.Arguments),
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			emit(StreamEvent{Type: EventTextDelta, Text: delta.Content})
+		}
+
+		for _, tc := range delta.ToolCalls {
+			index := len(toolCalls) - 1
+			switch {
+			case tc.Index != nil:
+				index = *tc.Index
+			case tc.ID != "" && (index < 0 || toolCalls[index].ID != tc.ID):
+				// Servers that omit the index start a call by sending its
+				// ID; deltas with neither belong to the last call.
+				index = len(toolCalls)
+			case index < 0:
+				index = 0
+			}
+			for index >= len(toolCalls) {
+				toolCalls = append(toolCalls, ToolCall{})
+				arguments = append(arguments, "")
+			}
+			if index < 0 {
+				continue
+			}
+
+			if tc.ID != "" {
+				toolCalls[index].ID = tc.ID
+			}
+			if tc.Function.Name != "" {
+				toolCalls[index].Name += tc.Function.Name
+				emit(StreamEvent{
+					Type:     EventToolCallStart,
+					Index:    index,
+					ToolCall: &ToolCall{ID: toolCalls[index].ID, Name: toolCalls[index].Name},
 				})
 			}
+			if tc.Function.Arguments != "" {
+				arguments[index] += tc.Function.Arguments
+				emit(StreamEvent{Type: EventToolCallDelta, Index: index, Text: tc.Function.Arguments})
+			}
+		}
+	}
+
+	if !received {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	result.Content = content.String()
+	if len(toolCalls) > 0 {
+		for i := range toolCalls {
+			toolCalls[i].Input = toolInput(json.RawMessage(arguments[i]))
 		}
 		result.ToolCalls = toolCalls
 	}
 
+	emit(StreamEvent{Type: EventUsage, Usage: &result.Usage})
 	return result, nil
 }
 
@@ -121,6 +175,65 @@ func (p *OpenAIProvider) GetModel() string {
 	return p.model
 }
 
+func (p *OpenAIProvider) SetModel(model string) {
+	p.model = model
+}
+
+func convertToOpenAIMessages(msg Message) []openai.ChatCompletionMessage {
+	if strings.ToLower(msg.Role) == "system" {
+		return []openai.ChatCompletionMessage{{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: msg.Text(),
+		}}
+	}
+
+	if strings.ToLower(msg.Role) == "assistant" {
+		assistant := openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: msg.Text(),
+		}
+		for _, block := range msg.Content {
+			if block.Type != BlockToolUse {
+				continue
+			}
+			assistant.ToolCalls = append(assistant.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(toolInput(block.Input)),
+				},
+			})
+		}
+		return []openai.ChatCompletionMessage{assistant}
+	}
+
+	result := []openai.ChatCompletionMessage{}
+	for _, block := range msg.Content {
+		if block.Type != BlockToolResult {
+			continue
+		}
+		content := block.Content
+		if block.IsError {
+			content = "Error: " + content
+		}
+		result = append(result, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    content,
+			ToolCallID: block.ToolUseID,
+		})
+	}
+
+	if text := msg.Text(); text != "" {
+		result = append(result, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: text,
+		})
+	}
+
+	return result
+}
+
 func convertToOpenAISchema(schema interface{}) (jsonschema.Definition, error) {
 	jsonBytes, err := json.Marshal(schema)
 	if err != nil {
@@ -3,27 +3,122 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/carlosarraes/lit/internal/tools"
 )
 
+const (
+	BlockText       = "text"
+	BlockToolUse    = "tool_use"
+	BlockToolResult = "tool_result"
+)
+
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
 }
 
 type ToolCall struct {
-	ID       string          `json:"id"`
-	Name     string          `json:"name"`
-	Input    json.RawMessage `json:"input"`
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 type Response struct {
 	Content   string     `json:"content"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Usage     Usage      `json:"usage"`
+}
+
+const (
+	EventTextDelta     = "text_delta"
+	EventToolCallStart = "tool_call_start"
+	EventToolCallDelta = "tool_call_delta"
+	EventUsage         = "usage"
+)
+
+// StreamEvent is delivered to ChatStream callbacks as the response arrives.
+// Text carries the text delta or, for tool call deltas, the partial JSON
+// arguments. Index identifies the content block or tool call it belongs to.
+type StreamEvent struct {
+	Type     string
+	Index    int
+	Text     string
+	ToolCall *ToolCall
+	Usage    *Usage
 }
 
 type Provider interface {
 	Chat(ctx context.Context, messages []Message, tools []tools.ToolDefinition) (*Response, error)
+	ChatStream(ctx context.Context, messages []Message, tools []tools.ToolDefinition, onEvent func(StreamEvent)) (*Response, error)
 	GetModel() string
-}
\ No newline at end of file
+	SetModel(model string)
+}
+
+func eventEmitter(onEvent func(StreamEvent)) func(StreamEvent) {
+	if onEvent == nil {
+		return func(StreamEvent) {}
+	}
+	return onEvent
+}
+
+func TextBlock(text string) ContentBlock {
+	return ContentBlock{Type: BlockText, Text: text}
+}
+
+func ToolUseBlock(call ToolCall) ContentBlock {
+	return ContentBlock{Type: BlockToolUse, ID: call.ID, Name: call.Name, Input: call.Input}
+}
+
+func ToolResultBlock(toolUseID, content string, isError bool) ContentBlock {
+	return ContentBlock{Type: BlockToolResult, ToolUseID: toolUseID, Content: content, IsError: isError}
+}
+
+func NewTextMessage(role, text string) Message {
+	return Message{Role: role, Content: []ContentBlock{TextBlock(text)}}
+}
+
+func NewAssistantMessage(response *Response) Message {
+	msg := Message{Role: "assistant"}
+	if response.Content != "" {
+		msg.Content = append(msg.Content, TextBlock(response.Content))
+	}
+	for _, call := range response.ToolCalls {
+		msg.Content = append(msg.Content, ToolUseBlock(call))
+	}
+	return msg
+}
+
+func (m Message) Text() string {
+	parts := []string{}
+	for _, block := range m.Content {
+		if block.Type == BlockText && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func toolInput(input json.RawMessage) json.RawMessage {
+	if len(input) == 0 {
+		return json.RawMessage("{}")
+	}
+	return input
+}
@@ -0,0 +1,86 @@
+package provider
+
+import "strings"
+
+const defaultContextWindow = 128000
+
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"claude-", 200000},
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+}
+
+// ContextWindow returns the context size in tokens for known models and a
+// conservative default for everything else.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens approximates the token count of a message using the usual
+// four characters per token heuristic.
+func EstimateTokens(msg Message) int {
+	chars := 0
+	for _, block := range msg.Content {
+		chars += len(block.Text) + len(block.Name) + len(block.Input) + len(block.Content)
+	}
+	return chars/4 + 4
+}
+
+func EstimateConversationTokens(messages []Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += EstimateTokens(msg)
+	}
+	return total
+}
+
+func (m Message) HasToolResults() bool {
+	for _, block := range m.Content {
+		if block.Type == BlockToolResult {
+			return true
+		}
+	}
+	return false
+}
+
+// prices are in US dollars per million input and output tokens.
+var prices = []struct {
+	prefix string
+	input  float64
+	output float64
+}{
+	{"claude-3-5-haiku", 0.80, 4},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-3-opus", 15, 75},
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10},
+	{"gpt-4-turbo", 10, 30},
+	{"gpt-3.5-turbo", 0.50, 1.50},
+}
+
+// EstimateCost returns the approximate price of usage for known models.
+func EstimateCost(model string, usage Usage) (float64, bool) {
+	model = strings.ToLower(model)
+	for _, price := range prices {
+		if strings.HasPrefix(model, price.prefix) {
+			return (float64(usage.InputTokens)*price.input + float64(usage.OutputTokens)*price.output) / 1e6, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,294 @@
+package session
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlosarraes/lit/internal/provider"
+)
+
+const (
+	entryMeta    = "meta"
+	entryMessage = "message"
+	entryReset   = "reset"
+)
+
+type entry struct {
+	Type      string             `json:"type"`
+	Timestamp time.Time          `json:"timestamp"`
+	ID        string             `json:"id,omitempty"`
+	Cwd       string             `json:"cwd,omitempty"`
+	Model     string             `json:"model,omitempty"`
+	Message   *provider.Message  `json:"message,omitempty"`
+	Messages  []provider.Message `json:"messages,omitempty"`
+}
+
+type Info struct {
+	ID          string
+	Path        string
+	Model       string
+	Created     time.Time
+	Updated     time.Time
+	FirstPrompt string
+	Messages    int
+}
+
+type Session struct {
+	ID   string
+	Path string
+
+	mu      sync.Mutex
+	file    *os.File
+	pending *entry
+	closed  bool
+}
+
+func Create(cwd, model string) (*Session, error) {
+	dir, err := projectDir(cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is only created once the first message is appended, so
+	// sessions that never got a prompt don't clutter the session list.
+	return &Session{
+		ID:      id,
+		Path:    filepath.Join(dir, id+".jsonl"),
+		pending: &entry{Type: entryMeta, Timestamp: time.Now(), ID: id, Cwd: cwd, Model: model},
+	}, nil
+}
+
+// Resume reopens a stored session for appending and returns its messages.
+// An empty id resumes the most recently updated session for cwd.
+func Resume(cwd, id string) (*Session, []provider.Message, error) {
+	if id == "" {
+		sessions, err := List(cwd)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(sessions) == 0 {
+			return nil, nil, fmt.Errorf("no previous sessions found for %s", cwd)
+		}
+		id = sessions[0].ID
+	}
+
+	if filepath.Base(id) != id {
+		return nil, nil, fmt.Errorf("invalid session id: %s", id)
+	}
+
+	dir, err := projectDir(cwd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := filepath.Join(dir, id+".jsonl")
+	_, messages, err := readSession(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, nil, err
+	}
+
+	return &Session{ID: id, Path: path}, messages, nil
+}
+
+func (s *Session) Append(msg provider.Message) error {
+	return s.write(entry{Type: entryMessage, Timestamp: time.Now(), Message: &msg})
+}
+
+// Reset records that the conversation was replaced by messages, e.g. after
+// compaction or /clear, so a resumed session starts from that history.
+func (s *Session) Reset(messages []provider.Message) error {
+	return s.write(entry{Type: entryReset, Timestamp: time.Now(), Messages: messages})
+}
+
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// List returns the sessions stored for cwd, most recently updated first.
+func List(cwd string) ([]Info, error) {
+	dir, err := projectDir(cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	sessions := []Info{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		info, _, err := readSession(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, info)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+
+	return sessions, nil
+}
+
+func (s *Session) write(e entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("session %s is closed", s.ID)
+	}
+
+	if s.file == nil {
+		if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+		file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open session file: %w", err)
+		}
+		s.file = file
+	}
+
+	entries := []entry{e}
+	if s.pending != nil {
+		entries = []entry{*s.pending, e}
+		s.pending = nil
+	}
+
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write session: %w", err)
+		}
+	}
+	return nil
+}
+
+func readSession(path string) (Info, []provider.Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Info{}, nil, err
+	}
+	defer file.Close()
+
+	info := Info{
+		ID:   strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+		Path: path,
+	}
+	messages := []provider.Message{}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var e entry
+			if err := json.Unmarshal(line, &e); err == nil {
+				switch e.Type {
+				case entryMeta:
+					info.Model = e.Model
+					info.Created = e.Timestamp
+				case entryMessage:
+					if e.Message != nil {
+						messages = append(messages, *e.Message)
+						if info.FirstPrompt == "" && e.Message.Role == "user" {
+							info.FirstPrompt = e.Message.Text()
+						}
+					}
+				case entryReset:
+					messages = append([]provider.Message{}, e.Messages...)
+				}
+				info.Updated = e.Timestamp
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	messages = repair(messages)
+	info.Messages = len(messages)
+	return info, messages, nil
+}
+
+// repair drops a trailing assistant turn whose tool calls never got results,
+// which happens when lit exits in the middle of running tools.
+func repair(messages []provider.Message) []provider.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+
+	last := messages[len(messages)-1]
+	if last.Role != "assistant" {
+		return messages
+	}
+	for _, block := range last.Content {
+		if block.Type == provider.BlockToolUse {
+			return messages[:len(messages)-1]
+		}
+	}
+	return messages
+}
+
+func projectDir(cwd string) (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dataDir = filepath.Join(homeDir, ".local", "share")
+	}
+
+	absCwd, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(absCwd))
+
+	return filepath.Join(dataDir, "lit", "sessions", hex.EncodeToString(hash[:8])), nil
+}
+
+func newID() (string, error) {
+	random := make([]byte, 3)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(random), nil
+}
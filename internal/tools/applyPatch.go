@@ -0,0 +1,837 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type ApplyPatchInput struct {
+	Patch string `json:"patch" jsonschema_description:"The patch to apply: a unified diff (as produced by git diff or diff -u) or a '*** Begin Patch' block"`
+}
+
+var (
+	ApplyPatchInputSchema = generateSchema[ApplyPatchInput]()
+	ApplyPatchDefinition  = ToolDefinition{
+		Name: "apply_patch",
+		Description: `Apply a patch that changes one or more files.
+
+IMPORTANT: You MUST use read_file first on every existing file the patch updates, renames or deletes.
+
+Accepts a standard unified diff ("--- a/path", "+++ b/path", "@@ -l,n +l,n @@" hunks; /dev/null creates or deletes a file; git rename headers are supported) or this simplified format:
+
+*** Begin Patch
+*** Add File: path/to/new.go
++line of the new file
+*** Update File: path/to/existing.go
+*** Move to: path/to/renamed.go
+@@ optional hint such as the enclosing function
+ context line
+-removed line
++added line
+*** Delete File: path/to/old.go
+*** End Patch
+
+Hunks are located by their content, so line numbers may be approximate; small whitespace differences and missing outer context lines are tolerated.
+The patch is atomic: if any hunk fails, no file is changed and every failing hunk is reported; if writing a file fails, the files already written are restored. Returns a unified diff of each change.
+`,
+		InputSchema: ApplyPatchInputSchema,
+		Function:    ApplyPatch,
+		Paths:       PatchPaths,
+	}
+)
+
+type patchOp int
+
+const (
+	patchUpdate patchOp = iota
+	patchAdd
+	patchDelete
+)
+
+type patchLine struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+type patchHunk struct {
+	header   string
+	numbered bool
+	oldStart int
+	oldCount int
+	lines    []patchLine
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+type filePatch struct {
+	op      patchOp
+	path    string
+	newPath string
+	hunks   []patchHunk
+}
+
+// pendingChange is a validated file change that has not been written yet.
+type pendingChange struct {
+	patch      filePatch
+	oldContent string
+	newContent string
+	mode       os.FileMode
+	notes      []string
+}
+
+func ApplyPatch(ctx context.Context, input json.RawMessage) (string, error) {
+	applyPatchInput := ApplyPatchInput{}
+	if err := json.Unmarshal(input, &applyPatchInput); err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(applyPatchInput.Patch) == "" {
+		return "", fmt.Errorf("invalid input parameters")
+	}
+
+	patches, err := parsePatch(applyPatchInput.Patch)
+	if err != nil {
+		return "", err
+	}
+	if len(patches) == 0 {
+		return "", fmt.Errorf("no file changes found in patch")
+	}
+
+	seen := map[string]bool{}
+	changes := []pendingChange{}
+	failures := []string{}
+	for _, p := range patches {
+		for _, path := range []string{p.path, p.newPath} {
+			if path == "" {
+				continue
+			}
+			if seen[trackerKey(path)] {
+				failures = append(failures, fmt.Sprintf("%s: the patch changes this file more than once", path))
+			}
+			seen[trackerKey(path)] = true
+		}
+
+		change, errs := preparePatch(p)
+		failures = append(failures, errs...)
+		changes = append(changes, change)
+	}
+
+	if len(failures) > 0 {
+		return "", fmt.Errorf("patch not applied, no files were changed:\n%s", strings.Join(failures, "\n"))
+	}
+
+	results := []string{}
+	undos := []func() error{}
+	for _, change := range changes {
+		result, undo, err := writeChange(change)
+		if err != nil {
+			if rollbackErr := rollbackChanges(undos); rollbackErr != nil {
+				return "", fmt.Errorf("%w; restoring the files already changed failed, they may be partially patched: %v", err, rollbackErr)
+			}
+			return "", fmt.Errorf("patch not applied, no files were changed: %w", err)
+		}
+		results = append(results, result)
+		undos = append(undos, undo)
+	}
+
+	return strings.Join(results, "\n"), nil
+}
+
+// PatchPaths returns every file an apply_patch input touches so permission
+// rules can match them.
+func PatchPaths(input json.RawMessage) []string {
+	applyPatchInput := ApplyPatchInput{}
+	if err := json.Unmarshal(input, &applyPatchInput); err != nil {
+		return nil
+	}
+	patches, err := parsePatch(applyPatchInput.Patch)
+	if err != nil {
+		return nil
+	}
+
+	paths := []string{}
+	for _, p := range patches {
+		paths = append(paths, p.path)
+		if p.newPath != "" {
+			paths = append(paths, p.newPath)
+		}
+	}
+	return paths
+}
+
+func preparePatch(p filePatch) (pendingChange, []string) {
+	change := pendingChange{patch: p, mode: 0644}
+	fail := func(format string, args ...any) (pendingChange, []string) {
+		return change, []string{p.path + ": " + fmt.Sprintf(format, args...)}
+	}
+
+	if p.op == patchAdd {
+		if _, err := os.Stat(p.path); err == nil {
+			return fail("file already exists; use an update patch instead")
+		}
+		var sb strings.Builder
+		noEOL := false
+		for _, h := range p.hunks {
+			for _, line := range h.lines {
+				if line.kind != '+' {
+					return fail("a new file may only contain added lines")
+				}
+				sb.WriteString(line.text + "\n")
+			}
+			noEOL = h.newNoEOL
+		}
+		change.newContent = sb.String()
+		if noEOL {
+			change.newContent = strings.TrimSuffix(change.newContent, "\n")
+		}
+		return change, nil
+	}
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if info.IsDir() {
+		return fail("is a directory")
+	}
+	if err := checkFreshRead(p.path, "apply_patch"); err != nil {
+		return change, []string{err.Error()}
+	}
+
+	// The old content is kept for deletes too, to restore the file if a
+	// later write fails.
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return fail("%v", err)
+	}
+	change.oldContent = string(content)
+	change.mode = info.Mode().Perm()
+	if p.op == patchDelete {
+		return change, nil
+	}
+
+	if p.newPath != "" {
+		if _, err := os.Stat(p.newPath); err == nil {
+			return fail("cannot move to %s: file already exists", p.newPath)
+		}
+	}
+
+	text := splitFileText(change.oldContent)
+	notes, failures := applyHunks(p.path, &text, p.hunks)
+	change.newContent = text.String()
+	change.notes = notes
+	return change, failures
+}
+
+// writeChange writes change to disk and returns a summary with the diff and
+// a function that undoes it. When it fails, whatever it already wrote is
+// undone before returning.
+func writeChange(change pendingChange) (string, func() error, error) {
+	p := change.patch
+	restore := func() error {
+		if err := writeFileAtomic(p.path, []byte(change.oldContent), change.mode); err != nil {
+			return fmt.Errorf("%s: %w", p.path, err)
+		}
+		MarkFileAsRead(p.path)
+		return nil
+	}
+
+	switch p.op {
+	case patchAdd:
+		if dir := filepath.Dir(p.path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return "", nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		if err := writeFileAtomic(p.path, []byte(change.newContent), change.mode); err != nil {
+			return "", nil, fmt.Errorf("failed to create %s: %w", p.path, err)
+		}
+		MarkFileAsRead(p.path)
+		undo := func() error { return os.Remove(p.path) }
+		return fmt.Sprintf("Created %s (%d lines)", p.path, len(splitLines(change.newContent))), undo, nil
+
+	case patchDelete:
+		if err := os.Remove(p.path); err != nil {
+			return "", nil, fmt.Errorf("failed to delete %s: %w", p.path, err)
+		}
+		return fmt.Sprintf("Deleted %s", p.path), restore, nil
+	}
+
+	target := p.path
+	summary := fmt.Sprintf("Updated %s", p.path)
+	undo := restore
+	if p.newPath != "" {
+		target = p.newPath
+		summary = fmt.Sprintf("Moved %s to %s", p.path, p.newPath)
+		undo = func() error {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return restore()
+		}
+		if dir := filepath.Dir(target); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return "", nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+	}
+
+	if err := writeFileAtomic(target, []byte(change.newContent), change.mode); err != nil {
+		return "", nil, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if target != p.path {
+		if err := os.Remove(p.path); err != nil {
+			if undoErr := undo(); undoErr != nil {
+				err = fmt.Errorf("%w; removing %s failed too: %v", err, target, undoErr)
+			}
+			return "", nil, fmt.Errorf("failed to remove %s after moving it: %w", p.path, err)
+		}
+	}
+	MarkFileAsRead(target)
+
+	var sb strings.Builder
+	sb.WriteString(summary + "\n")
+	for _, note := range change.notes {
+		sb.WriteString(note + "\n")
+	}
+	sb.WriteString(unifiedDiff(target, change.oldContent, change.newContent))
+	return strings.TrimSuffix(sb.String(), "\n"), undo, nil
+}
+
+// rollbackChanges undoes written changes, newest first, and reports every
+// file it could not restore.
+func rollbackChanges(undos []func() error) error {
+	errs := []error{}
+	for i := len(undos) - 1; i >= 0; i-- {
+		if err := undos[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fileText is a file split into lines, remembering its line endings so the
+// patched file is written back the same way.
+type fileText struct {
+	lines           []string
+	crlf            bool
+	trailingNewline bool
+}
+
+func splitFileText(content string) fileText {
+	text := fileText{trailingNewline: true}
+	if content == "" {
+		return text
+	}
+	text.trailingNewline = strings.HasSuffix(content, "\n")
+	text.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if strings.HasSuffix(text.lines[0], "\r") {
+		text.crlf = true
+		for i, line := range text.lines {
+			text.lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+	return text
+}
+
+func (t fileText) String() string {
+	if len(t.lines) == 0 {
+		return ""
+	}
+	eol := "\n"
+	if t.crlf {
+		eol = "\r\n"
+	}
+	content := strings.Join(t.lines, eol)
+	if t.trailingNewline {
+		content += eol
+	}
+	return content
+}
+
+// maxPatchFuzz is how many outer context lines may be dropped from each end
+// of a hunk that doesn't match, like patch's --fuzz.
+const maxPatchFuzz = 2
+
+var lineMatchers = []struct {
+	name  string
+	equal func(a, b string) bool
+}{
+	{"", func(a, b string) bool { return a == b }},
+	{"trailing whitespace", func(a, b string) bool {
+		return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t")
+	}},
+	{"whitespace", func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) }},
+}
+
+func applyHunks(path string, text *fileText, hunks []patchHunk) ([]string, []string) {
+	notes := []string{}
+	failures := []string{}
+
+	// Hunks are expected in order: after the previous one, or at their line
+	// number adjusted by the lines earlier hunks added or removed.
+	next, offset := 0, 0
+	for n, h := range hunks {
+		hint := next
+		if h.numbered {
+			hint = h.oldStart - 1 + offset
+			if h.oldCount == 0 {
+				hint = h.oldStart + offset
+			}
+		}
+
+		pos, matched, note := locateHunk(text.lines, h, hint)
+		if pos < 0 {
+			failures = append(failures, fmt.Sprintf("%s: hunk %d%s: could not find the lines to replace; re-read the file and regenerate this hunk", path, n+1, describeHunk(h)))
+			continue
+		}
+		if note != "" {
+			notes = append(notes, fmt.Sprintf("hunk %d applied with %s", n+1, note))
+		}
+
+		oldLen := matched.oldLen()
+		replacement := matched.apply(text.lines[pos : pos+oldLen])
+		lines := append([]string{}, text.lines[:pos]...)
+		lines = append(lines, replacement...)
+		lines = append(lines, text.lines[pos+oldLen:]...)
+		text.lines = lines
+
+		if pos+len(replacement) == len(text.lines) && (h.oldNoEOL || h.newNoEOL) {
+			text.trailingNewline = !h.newNoEOL
+		}
+
+		next = pos + len(replacement)
+		offset += len(replacement) - oldLen
+	}
+
+	return notes, failures
+}
+
+func describeHunk(h patchHunk) string {
+	if h.header == "" {
+		return ""
+	}
+	return " (" + h.header + ")"
+}
+
+// locateHunk finds where h applies, trying an exact match first and then
+// progressively looser whitespace and context fuzz. It returns the position,
+// the possibly trimmed hunk that matched and a note describing the fuzz.
+func locateHunk(lines []string, h patchHunk, hint int) (int, patchHunk, string) {
+	if h.oldLen() == 0 {
+		if !h.numbered {
+			return len(lines), h, ""
+		}
+		return min(max(hint, 0), len(lines)), h, ""
+	}
+
+	for fuzz := 0; fuzz <= maxPatchFuzz; fuzz++ {
+		trimmed, leading, ok := h.trimContext(fuzz)
+		if !ok {
+			break
+		}
+		old := trimmed.oldLines()
+		for _, matcher := range lineMatchers {
+			pos := findLines(lines, old, hint+leading, matcher.equal)
+			if pos < 0 {
+				continue
+			}
+			notes := []string{}
+			if fuzz > 0 {
+				notes = append(notes, fmt.Sprintf("fuzz %d", fuzz))
+			}
+			if matcher.name != "" {
+				notes = append(notes, "differences in "+matcher.name+" ignored")
+			}
+			return pos, trimmed, strings.Join(notes, ", ")
+		}
+	}
+	return -1, h, ""
+}
+
+// findLines returns the position where old matches lines, preferring the one
+// closest to hint.
+func findLines(lines, old []string, hint int, equal func(a, b string) bool) int {
+	last := len(lines) - len(old)
+	if last < 0 {
+		return -1
+	}
+	hint = min(max(hint, 0), last)
+
+	matchesAt := func(pos int) bool {
+		for i, line := range old {
+			if !equal(lines[pos+i], line) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for d := 0; hint-d >= 0 || hint+d <= last; d++ {
+		if hint+d <= last && matchesAt(hint+d) {
+			return hint + d
+		}
+		if d > 0 && hint-d >= 0 && matchesAt(hint-d) {
+			return hint - d
+		}
+	}
+	return -1
+}
+
+func (h patchHunk) oldLines() []string {
+	old := []string{}
+	for _, line := range h.lines {
+		if line.kind != '+' {
+			old = append(old, line.text)
+		}
+	}
+	return old
+}
+
+func (h patchHunk) oldLen() int {
+	n := 0
+	for _, line := range h.lines {
+		if line.kind != '+' {
+			n++
+		}
+	}
+	return n
+}
+
+// apply returns the replacement for the matched lines. Context lines are
+// taken from the file so whitespace-fuzzy matches keep the original text.
+func (h patchHunk) apply(matched []string) []string {
+	result := []string{}
+	i := 0
+	for _, line := range h.lines {
+		switch line.kind {
+		case ' ':
+			result = append(result, matched[i])
+			i++
+		case '-':
+			i++
+		case '+':
+			result = append(result, line.text)
+		}
+	}
+	return result
+}
+
+// trimContext drops up to n context lines from each end of the hunk. ok is
+// false when there is nothing more to drop.
+func (h patchHunk) trimContext(n int) (patchHunk, int, bool) {
+	leading := 0
+	for leading < n && leading < len(h.lines) && h.lines[leading].kind == ' ' {
+		leading++
+	}
+	trailing := 0
+	for trailing < n && trailing < len(h.lines)-leading && h.lines[len(h.lines)-1-trailing].kind == ' ' {
+		trailing++
+	}
+	if n > 0 && leading < n && trailing < n {
+		return h, 0, false
+	}
+
+	trimmed := h
+	trimmed.lines = h.lines[leading : len(h.lines)-trailing]
+	if trimmed.oldLen() == 0 {
+		return h, 0, false
+	}
+	return trimmed, leading, true
+}
+
+func parsePatch(patch string) ([]filePatch, error) {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	lines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "*** Begin Patch") {
+			return parseSimplePatch(lines)
+		}
+		break
+	}
+	return parseUnifiedDiff(lines)
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func parseUnifiedDiff(lines []string) ([]filePatch, error) {
+	patches := []filePatch{}
+	current := -1
+	hasFileHeader := false
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			patches = append(patches, filePatch{})
+			current = len(patches) - 1
+			hasFileHeader = false
+			if oldPath, newPath, ok := parseGitDiffHeader(line); ok {
+				patches[current].path = oldPath
+				if newPath != oldPath {
+					patches[current].newPath = newPath
+				}
+			}
+			i++
+
+		case isFileHeader(lines, i):
+			if current < 0 || hasFileHeader || len(patches[current].hunks) > 0 {
+				patches = append(patches, filePatch{})
+				current = len(patches) - 1
+			}
+			hasFileHeader = true
+			oldPath, newPath := headerPath(lines[i][4:]), headerPath(lines[i+1][4:])
+			if strings.HasPrefix(oldPath, "a/") && (strings.HasPrefix(newPath, "b/") || newPath == "/dev/null") {
+				oldPath = oldPath[2:]
+			}
+			if strings.HasPrefix(newPath, "b/") && (oldPath == "/dev/null" || strings.HasPrefix(lines[i][4:], "a/")) {
+				newPath = newPath[2:]
+			}
+
+			p := &patches[current]
+			switch {
+			case oldPath == "/dev/null":
+				p.op, p.path, p.newPath = patchAdd, newPath, ""
+			case newPath == "/dev/null":
+				p.op, p.path, p.newPath = patchDelete, oldPath, ""
+			default:
+				p.path, p.newPath = oldPath, ""
+				if newPath != oldPath {
+					p.newPath = newPath
+				}
+			}
+			i += 2
+
+		case strings.HasPrefix(line, "@@"):
+			if current < 0 {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			h, next := parseUnifiedHunk(lines, i)
+			patches[current].hunks = append(patches[current].hunks, h)
+			i = next
+
+		case current >= 0 && strings.HasPrefix(line, "rename from "):
+			patches[current].path = strings.TrimPrefix(line, "rename from ")
+			i++
+
+		case current >= 0 && strings.HasPrefix(line, "rename to "):
+			patches[current].newPath = strings.TrimPrefix(line, "rename to ")
+			i++
+
+		case current >= 0 && strings.HasPrefix(line, "new file mode"):
+			patches[current].op = patchAdd
+			i++
+
+		case current >= 0 && strings.HasPrefix(line, "deleted file mode"):
+			patches[current].op = patchDelete
+			i++
+
+		default:
+			i++
+		}
+	}
+
+	for _, p := range patches {
+		if p.path == "" {
+			return nil, fmt.Errorf("patch is missing a file name")
+		}
+		if p.op == patchUpdate && p.newPath == "" && len(p.hunks) == 0 {
+			return nil, fmt.Errorf("%s: patch has no hunks", p.path)
+		}
+	}
+	return patches, nil
+}
+
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+func headerPath(value string) string {
+	if tab := strings.Index(value, "\t"); tab >= 0 {
+		value = value[:tab]
+	}
+	return strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+func parseGitDiffHeader(line string) (string, string, bool) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if !strings.HasPrefix(rest, "a/") {
+		return "", "", false
+	}
+	sep := strings.Index(rest, " b/")
+	if sep < 0 {
+		return "", "", false
+	}
+	return rest[2:sep], rest[sep+3:], true
+}
+
+// parseUnifiedHunk reads the hunk starting at lines[start]. The line counts
+// in the header are used when present, but hunks whose counts are off are
+// still read up to the next header.
+func parseUnifiedHunk(lines []string, start int) (patchHunk, int) {
+	h := patchHunk{header: lines[start]}
+	oldLeft, newLeft := -1, -1
+	if m := hunkHeaderRegex.FindStringSubmatch(lines[start]); m != nil {
+		h.numbered = true
+		h.oldStart, _ = strconv.Atoi(m[1])
+		h.oldCount, oldLeft = 1, 1
+		if m[2] != "" {
+			h.oldCount, _ = strconv.Atoi(m[2])
+			oldLeft = h.oldCount
+		}
+		newLeft = 1
+		if m[4] != "" {
+			newLeft, _ = strconv.Atoi(m[4])
+		}
+	}
+	counted := oldLeft >= 0
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "\\") {
+			if len(h.lines) > 0 {
+				switch h.lines[len(h.lines)-1].kind {
+				case '-':
+					h.oldNoEOL = true
+				case '+':
+					h.newNoEOL = true
+				default:
+					h.oldNoEOL, h.newNoEOL = true, true
+				}
+			}
+			continue
+		}
+
+		remaining := counted && (oldLeft > 0 || newLeft > 0)
+		if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff --git ") {
+			break
+		}
+		if !remaining && isFileHeader(lines, i) {
+			break
+		}
+
+		var kind byte
+		switch {
+		case line == "" && (remaining || !counted):
+			kind = ' '
+		case line != "" && (line[0] == ' ' || line[0] == '-' || line[0] == '+'):
+			kind = line[0]
+		}
+		if kind == 0 {
+			break
+		}
+
+		text := ""
+		if line != "" {
+			text = line[1:]
+		}
+		h.lines = append(h.lines, patchLine{kind: kind, text: text})
+		if kind != '+' {
+			oldLeft--
+		}
+		if kind != '-' {
+			newLeft--
+		}
+	}
+
+	if !counted {
+		h.lines = trimTrailingBlankContext(h.lines)
+	}
+	return h, i
+}
+
+func trimTrailingBlankContext(lines []patchLine) []patchLine {
+	for len(lines) > 0 && lines[len(lines)-1].kind == ' ' && lines[len(lines)-1].text == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func parseSimplePatch(lines []string) ([]filePatch, error) {
+	patches := []filePatch{}
+	current := -1
+
+	addHunkLine := func(line patchLine) {
+		p := &patches[current]
+		if len(p.hunks) == 0 {
+			p.hunks = append(p.hunks, patchHunk{})
+		}
+		h := &p.hunks[len(p.hunks)-1]
+		h.lines = append(h.lines, line)
+	}
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "*** Begin Patch"):
+		case strings.HasPrefix(line, "*** End Patch"):
+			return finishSimplePatch(patches)
+		case strings.HasPrefix(line, "*** Add File: "):
+			patches = append(patches, filePatch{op: patchAdd, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Add File: "))})
+			current = len(patches) - 1
+		case strings.HasPrefix(line, "*** Delete File: "):
+			patches = append(patches, filePatch{op: patchDelete, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Delete File: "))})
+			current = len(patches) - 1
+		case strings.HasPrefix(line, "*** Update File: "):
+			patches = append(patches, filePatch{op: patchUpdate, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Update File: "))})
+			current = len(patches) - 1
+		case strings.HasPrefix(line, "*** Move to: "):
+			if current < 0 || patches[current].op != patchUpdate {
+				return nil, fmt.Errorf("line %d: '*** Move to' must follow '*** Update File'", i+1)
+			}
+			patches[current].newPath = strings.TrimSpace(strings.TrimPrefix(line, "*** Move to: "))
+		case strings.HasPrefix(line, "*** End of File"):
+		case strings.HasPrefix(line, "@@"):
+			if current < 0 {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			p := &patches[current]
+			p.hunks = append(p.hunks, patchHunk{header: strings.TrimSpace(line)})
+		case current < 0:
+			if strings.TrimSpace(line) != "" {
+				return nil, fmt.Errorf("line %d: expected a '*** Add File', '*** Update File' or '*** Delete File' header", i+1)
+			}
+		case patches[current].op == patchDelete:
+			if strings.TrimSpace(line) != "" {
+				return nil, fmt.Errorf("line %d: unexpected content after '*** Delete File'", i+1)
+			}
+		case line == "" && patches[current].op == patchAdd:
+			addHunkLine(patchLine{kind: '+'})
+		case line == "":
+			addHunkLine(patchLine{kind: ' '})
+		case line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			addHunkLine(patchLine{kind: line[0], text: line[1:]})
+		default:
+			return nil, fmt.Errorf("line %d: hunk lines must start with ' ', '-' or '+'", i+1)
+		}
+	}
+
+	return finishSimplePatch(patches)
+}
+
+func finishSimplePatch(patches []filePatch) ([]filePatch, error) {
+	for i := range patches {
+		p := &patches[i]
+		if p.path == "" {
+			return nil, fmt.Errorf("patch is missing a file name")
+		}
+		for j := range p.hunks {
+			p.hunks[j].lines = trimTrailingBlankContext(p.hunks[j].lines)
+		}
+		if p.op == patchUpdate && p.newPath == "" && len(p.hunks) == 0 {
+			return nil, fmt.Errorf("%s: patch has no hunks", p.path)
+		}
+	}
+	return patches, nil
+}
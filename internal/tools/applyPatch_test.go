@@ -0,0 +1,407 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func patchLines(patch string) []string {
+	return strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+}
+
+func TestParseUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []filePatch
+	}{
+		{
+			name: "update",
+			patch: `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ func main() {
+ a
+-b
++c
+ d
+`,
+			want: []filePatch{{op: patchUpdate, path: "main.go", hunks: []patchHunk{{
+				header: "@@ -1,3 +1,3 @@ func main() {", numbered: true, oldStart: 1, oldCount: 3,
+				lines: []patchLine{{' ', "a"}, {'-', "b"}, {'+', "c"}, {' ', "d"}},
+			}}}},
+		},
+		{
+			name: "add and delete",
+			patch: `--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`,
+			want: []filePatch{
+				{op: patchAdd, path: "new.txt", hunks: []patchHunk{{
+					header: "@@ -0,0 +1 @@", numbered: true, oldStart: 0, oldCount: 0,
+					lines: []patchLine{{'+', "hello"}},
+				}}},
+				{op: patchDelete, path: "old.txt", hunks: []patchHunk{{
+					header: "@@ -1 +0,0 @@", numbered: true, oldStart: 1, oldCount: 1,
+					lines: []patchLine{{'-', "bye"}},
+				}}},
+			},
+		},
+		{
+			name: "git rename without hunks",
+			patch: `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`,
+			want: []filePatch{{op: patchUpdate, path: "old.go", newPath: "new.go"}},
+		},
+		{
+			name: "no newline at end of file",
+			patch: `--- a/x
++++ b/x
+@@ -1 +1 @@
+-old
+\ No newline at end of file
++new
+\ No newline at end of file
+`,
+			want: []filePatch{{op: patchUpdate, path: "x", hunks: []patchHunk{{
+				header: "@@ -1 +1 @@", numbered: true, oldStart: 1, oldCount: 1,
+				lines:    []patchLine{{'-', "old"}, {'+', "new"}},
+				oldNoEOL: true, newNoEOL: true,
+			}}}},
+		},
+		{
+			name: "wrong counts read up to the next hunk",
+			patch: `--- a/x
++++ b/x
+@@ -1,1 +1,1 @@
+ a
+-b
++c
+@@ -10,2 +10,2 @@
+-y
++z
+`,
+			want: []filePatch{{op: patchUpdate, path: "x", hunks: []patchHunk{
+				{header: "@@ -1,1 +1,1 @@", numbered: true, oldStart: 1, oldCount: 1, lines: []patchLine{{' ', "a"}, {'-', "b"}, {'+', "c"}}},
+				{header: "@@ -10,2 +10,2 @@", numbered: true, oldStart: 10, oldCount: 2, lines: []patchLine{{'-', "y"}, {'+', "z"}}},
+			}}},
+		},
+		{
+			name: "hunk header without numbers",
+			patch: `--- x
++++ x
+@@
+ a
+-b
+
+`,
+			want: []filePatch{{op: patchUpdate, path: "x", hunks: []patchHunk{{
+				header: "@@", lines: []patchLine{{' ', "a"}, {'-', "b"}},
+			}}}},
+		},
+		{
+			name: "multiple files",
+			patch: `--- a/one
++++ b/one
+@@ -1 +1 @@
+-1
++one
+--- a/two
++++ b/two
+@@ -1 +1 @@
+-2
++two
+`,
+			want: []filePatch{
+				{op: patchUpdate, path: "one", hunks: []patchHunk{{header: "@@ -1 +1 @@", numbered: true, oldStart: 1, oldCount: 1, lines: []patchLine{{'-', "1"}, {'+', "one"}}}}},
+				{op: patchUpdate, path: "two", hunks: []patchHunk{{header: "@@ -1 +1 @@", numbered: true, oldStart: 1, oldCount: 1, lines: []patchLine{{'-', "2"}, {'+', "two"}}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUnifiedDiff(patchLines(tt.patch))
+			if err != nil {
+				t.Fatalf("parseUnifiedDiff() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUnifiedDiff() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnifiedDiffErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"hunk without file header", "@@ -1 +1 @@\n-a\n+b\n"},
+		{"update without hunks", "--- a/x\n+++ b/x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseUnifiedDiff(patchLines(tt.patch)); err == nil {
+				t.Error("parseUnifiedDiff() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseSimplePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  []filePatch
+	}{
+		{
+			name: "add update move and delete",
+			patch: `*** Begin Patch
+*** Add File: new.txt
++first
+
++third
+*** Update File: a.go
+*** Move to: b.go
+@@ func main
+ keep
+-old
++new
+*** Delete File: gone.txt
+*** End Patch
+`,
+			want: []filePatch{
+				{op: patchAdd, path: "new.txt", hunks: []patchHunk{{lines: []patchLine{{'+', "first"}, {'+', ""}, {'+', "third"}}}}},
+				{op: patchUpdate, path: "a.go", newPath: "b.go", hunks: []patchHunk{{
+					header: "@@ func main", lines: []patchLine{{' ', "keep"}, {'-', "old"}, {'+', "new"}},
+				}}},
+				{op: patchDelete, path: "gone.txt"},
+			},
+		},
+		{
+			name: "blank lines are context and trailing ones are dropped",
+			patch: `*** Begin Patch
+*** Update File: x
+ a
+
+-b
++c
+
+
+*** End Patch
+`,
+			want: []filePatch{{op: patchUpdate, path: "x", hunks: []patchHunk{{
+				lines: []patchLine{{' ', "a"}, {' ', ""}, {'-', "b"}, {'+', "c"}},
+			}}}},
+		},
+		{
+			name: "several hunks",
+			patch: `*** Begin Patch
+*** Update File: x
+@@
+-a
++b
+@@
+-c
++d
+*** End of File
+*** End Patch
+`,
+			want: []filePatch{{op: patchUpdate, path: "x", hunks: []patchHunk{
+				{header: "@@", lines: []patchLine{{'-', "a"}, {'+', "b"}}},
+				{header: "@@", lines: []patchLine{{'-', "c"}, {'+', "d"}}},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSimplePatch(patchLines(tt.patch))
+			if err != nil {
+				t.Fatalf("parseSimplePatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSimplePatch() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSimplePatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"move without update", "*** Begin Patch\n*** Add File: x\n*** Move to: y\n*** End Patch\n"},
+		{"hunk without file", "*** Begin Patch\n@@\n-a\n*** End Patch\n"},
+		{"content before file header", "*** Begin Patch\n-a\n*** End Patch\n"},
+		{"content after delete", "*** Begin Patch\n*** Delete File: x\n-a\n*** End Patch\n"},
+		{"bad line prefix", "*** Begin Patch\n*** Update File: x\nfoo\n*** End Patch\n"},
+		{"update without hunks", "*** Begin Patch\n*** Update File: x\n*** End Patch\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSimplePatch(patchLines(tt.patch)); err == nil {
+				t.Error("parseSimplePatch() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLocateHunk(t *testing.T) {
+	file := []string{"func a() {", "\tx := 1", "\treturn x", "}", "", "func b() {", "\tx := 1", "\treturn x", "}"}
+
+	tests := []struct {
+		name  string
+		lines []patchLine
+		hint  int
+		pos   int
+		note  string
+	}{
+		{"exact", []patchLine{{' ', "func b() {"}, {'-', "\tx := 1"}}, 0, 5, ""},
+		{"closest to hint", []patchLine{{'-', "\tx := 1"}, {' ', "\treturn x"}}, 6, 6, ""},
+		{"closest to hint before it", []patchLine{{'-', "\tx := 1"}, {' ', "\treturn x"}}, 3, 1, ""},
+		{"trailing whitespace", []patchLine{{' ', "func a() {  "}, {'-', "\tx := 1"}}, 0, 0, "differences in trailing whitespace ignored"},
+		{"indentation", []patchLine{{' ', "func a() {"}, {'-', "    x := 1"}}, 0, 0, "differences in whitespace ignored"},
+		{"fuzz drops outer context", []patchLine{{' ', "func c() {"}, {'-', "\tx := 1"}, {' ', "\treturn x"}, {' ', "}"}, {' ', "// end"}}, 6, 6, "fuzz 1"},
+		{"not found", []patchLine{{'-', "missing"}}, 0, -1, ""},
+		{"pure addition appends", []patchLine{{'+', "// new"}}, 0, len(file), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, _, note := locateHunk(file, patchHunk{lines: tt.lines}, tt.hint)
+			if pos != tt.pos || note != tt.note {
+				t.Errorf("locateHunk() = %d, %q, want %d, %q", pos, note, tt.pos, tt.note)
+			}
+		})
+	}
+}
+
+func TestTrimContext(t *testing.T) {
+	hunk := patchHunk{lines: []patchLine{{' ', "a"}, {' ', "b"}, {'-', "c"}, {'+', "C"}, {' ', "d"}}}
+
+	tests := []struct {
+		name    string
+		hunk    patchHunk
+		n       int
+		lines   []patchLine
+		leading int
+		ok      bool
+	}{
+		{"no fuzz", hunk, 0, hunk.lines, 0, true},
+		{"one line from each end", hunk, 1, []patchLine{{' ', "b"}, {'-', "c"}, {'+', "C"}}, 1, true},
+		{"only as much as there is", hunk, 2, []patchLine{{'-', "c"}, {'+', "C"}}, 2, true},
+		{"nothing left to drop", patchHunk{lines: []patchLine{{'-', "c"}}}, 1, nil, 0, false},
+		{"keeps at least one old line", patchHunk{lines: []patchLine{{' ', "a"}, {'+', "b"}}}, 1, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, leading, ok := tt.hunk.trimContext(tt.n)
+			if ok != tt.ok {
+				t.Fatalf("trimContext(%d) ok = %v, want %v", tt.n, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if leading != tt.leading || !reflect.DeepEqual(got.lines, tt.lines) {
+				t.Errorf("trimContext(%d) = %v, %d, want %v, %d", tt.n, got.lines, leading, tt.lines, tt.leading)
+			}
+		})
+	}
+}
+
+func TestFileTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		crlf    bool
+	}{
+		{"empty", "", false},
+		{"lf", "a\nb\n", false},
+		{"lf without trailing newline", "a\nb", false},
+		{"crlf", "a\r\nb\r\n", true},
+		{"crlf without trailing newline", "a\r\nb", true},
+		{"blank lines", "a\r\n\r\nb\r\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := splitFileText(tt.content)
+			if text.crlf != tt.crlf {
+				t.Errorf("crlf = %v, want %v", text.crlf, tt.crlf)
+			}
+			for _, line := range text.lines {
+				if strings.HasSuffix(line, "\r") {
+					t.Errorf("line %q keeps its carriage return", line)
+				}
+			}
+			if got := text.String(); got != tt.content {
+				t.Errorf("String() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestApplyHunksKeepsCRLF(t *testing.T) {
+	text := splitFileText("one\r\ntwo\r\nthree\r\n")
+	hunks := []patchHunk{{lines: []patchLine{{' ', "one"}, {'-', "two"}, {'+', "2"}, {'+', "2.5"}, {' ', "three"}}}}
+
+	if _, failures := applyHunks("x", &text, hunks); len(failures) > 0 {
+		t.Fatalf("applyHunks() failures = %v", failures)
+	}
+	if got, want := text.String(), "one\r\n2\r\n2.5\r\nthree\r\n"; got != want {
+		t.Errorf("patched content = %q, want %q", got, want)
+	}
+}
+
+func TestApplyPatchRollsBackOnWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	blocker := filepath.Join(dir, "blocker")
+	for path, content := range map[string]string{first: "old\n", second: "two\n", blocker: ""} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		MarkFileAsRead(path)
+	}
+
+	// Moving into a path below a regular file passes validation but fails
+	// when the directory is created, after first.txt has been written.
+	patch := "*** Begin Patch\n" +
+		"*** Update File: " + first + "\n-old\n+new\n" +
+		"*** Update File: " + second + "\n*** Move to: " + filepath.Join(blocker, "second.txt") + "\n-two\n+2\n" +
+		"*** End Patch\n"
+	input, _ := json.Marshal(ApplyPatchInput{Patch: patch})
+
+	if _, err := ApplyPatch(context.Background(), input); err == nil {
+		t.Fatal("ApplyPatch() error = nil, want a write failure")
+	}
+	for path, want := range map[string]string{first: "old\n", second: "two\n"} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q after a failed patch, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
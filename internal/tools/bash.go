@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// maxBashOutput is how much of stdout and of stderr is kept; longer output
+// keeps its beginning and end.
+const maxBashOutput = 32 * 1024
+
+const (
+	SandboxOff  = "off"
+	SandboxAuto = "auto"
+	SandboxOn   = "on"
+)
+
+// BashSettings configures the bash tool. Commands start in Root or below
+// it. With Sandbox set to "auto" or "on" they run under bubblewrap without
+// network and can only write to Root, Writable and a private /tmp; "auto"
+// runs them unsandboxed when bubblewrap is unavailable, "on" refuses to.
+type BashSettings struct {
+	Root     string
+	Sandbox  string
+	Writable []string
+}
+
+var bashSettings BashSettings
+
+func SetBashSettings(settings BashSettings) {
+	bashSettings = settings
+}
+
+type BashInput struct {
+	Command string `json:"command" jsonschema_description:"The command to run with /bin/sh -c, e.g. 'go test ./...' or 'make lint'"`
+	Workdir string `json:"workdir,omitempty" jsonschema_description:"Directory to run the command in, relative to the project directory. It must be inside the project. Defaults to the project directory"`
+	Timeout int    `json:"timeout,omitempty" jsonschema_description:"Timeout in seconds. The configured tool timeout still applies and wins when it is shorter"`
+}
+
+var (
+	BashInputSchema = generateSchema[BashInput]()
+	BashDefinition  = ToolDefinition{
+		Name: "bash",
+		Description: `Run a shell command in the project directory and return its output and exit code.
+
+Use it to build, test, lint or run project scripts and verify changes. stdin is empty, so commands must not wait for input.
+Output is limited to the first and last 16KB of stdout and of stderr; a non-zero exit code is reported, not treated as a failure of the tool.
+Prefer the dedicated tools for reading, searching, editing and git operations.
+
+SAFETY: Unless the user allowed it beforehand, they will be shown the command and asked for confirmation.
+The command may run in a sandbox without network access that can only write inside the project directory.
+
+Examples:
+- Run the tests: command="go test ./..."
+- Run in a subdirectory: command="npm test", workdir="web"
+- Limit the runtime: command="make lint", timeout=60
+`,
+		InputSchema: BashInputSchema,
+		Function:    Bash,
+	}
+)
+
+func Bash(ctx context.Context, input json.RawMessage) (string, error) {
+	bashInput := BashInput{}
+	if err := json.Unmarshal(input, &bashInput); err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(bashInput.Command) == "" {
+		return "", fmt.Errorf("command is required")
+	}
+	if bashInput.Timeout < 0 {
+		return "", fmt.Errorf("timeout must not be negative")
+	}
+
+	root := bashSettings.Root
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		root = cwd
+	}
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := bashWorkdir(root, bashInput.Workdir)
+	if err != nil {
+		return "", err
+	}
+
+	name, args, notes, err := bashCommand(root, dir, bashInput.Command)
+	if err != nil {
+		return "", err
+	}
+
+	runCtx := ctx
+	if bashInput.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, time.Duration(bashInput.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	stdout := &cappedBuffer{limit: maxBashOutput}
+	stderr := &cappedBuffer{limit: maxBashOutput}
+	cmd := command(runCtx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	status := "Exit code: 0"
+	if err != nil {
+		var exitError *exec.ExitError
+		switch {
+		case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+			status = fmt.Sprintf("Timed out after %ds; the command was killed", bashInput.Timeout)
+		case errors.Is(err, exec.ErrWaitDelay):
+			notes = append(notes, "Background processes kept the output open; output after the command exited was dropped")
+		case errors.As(err, &exitError) && exitError.ExitCode() >= 0:
+			status = fmt.Sprintf("Exit code: %d", exitError.ExitCode())
+		case errors.As(err, &exitError):
+			status = fmt.Sprintf("Terminated: %s", exitError.ProcessState)
+		default:
+			return "", fmt.Errorf("failed to run command: %w", err)
+		}
+	}
+
+	var sb strings.Builder
+	if out := stdout.String(); out != "" {
+		sb.WriteString("stdout:\n" + strings.TrimRight(out, "\n") + "\n\n")
+	}
+	if out := stderr.String(); out != "" {
+		sb.WriteString("stderr:\n" + strings.TrimRight(out, "\n") + "\n\n")
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("(no output)\n\n")
+	}
+	sb.WriteString(status)
+	for _, note := range notes {
+		sb.WriteString("\n📝 " + note)
+	}
+	return sb.String(), nil
+}
+
+// bashWorkdir resolves workdir against root, which has no symlinks, and
+// makes sure it stays inside root once symlinks are followed.
+func bashWorkdir(root, workdir string) (string, error) {
+	dir := root
+	if workdir != "" {
+		dir = workdir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		var err error
+		if dir, err = filepath.EvalSymlinks(dir); err != nil {
+			return "", err
+		}
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("workdir %s is outside the project directory %s", workdir, root)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("workdir %s is not a directory", workdir)
+	}
+	return dir, nil
+}
+
+// bashCommand returns the program and arguments that run script in dir,
+// wrapped in bubblewrap when the sandbox is enabled and available.
+func bashCommand(root, dir, script string) (string, []string, []string, error) {
+	shell := []string{"/bin/sh", "-c", script}
+
+	switch bashSettings.Sandbox {
+	case SandboxAuto, SandboxOn:
+		bwrap, err := findBubblewrap()
+		if err != nil {
+			if bashSettings.Sandbox == SandboxOn {
+				return "", nil, nil, fmt.Errorf("the bash sandbox is required but unavailable: %w", err)
+			}
+			return shell[0], shell[1:], []string{fmt.Sprintf("Not sandboxed: %v", err)}, nil
+		}
+		args := append(bubblewrapArgs(root, dir), shell...)
+		return bwrap, args, []string{fmt.Sprintf("Sandboxed: no network, writes limited to %s", root)}, nil
+	}
+
+	return shell[0], shell[1:], nil, nil
+}
+
+func findBubblewrap() (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("sandboxing is only supported on Linux")
+	}
+	path, err := exec.LookPath("bwrap")
+	if err != nil {
+		return "", fmt.Errorf("bubblewrap (bwrap) is not installed")
+	}
+	return path, nil
+}
+
+// bubblewrapArgs mounts the whole filesystem read-only, then a private /tmp
+// and writable binds for root and the configured extra paths.
+func bubblewrapArgs(root, dir string) []string {
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--unshare-net",
+		"--unshare-pid",
+		"--new-session",
+		"--die-with-parent",
+		"--bind", root, root,
+	}
+
+	for _, path := range bashSettings.Writable {
+		if strings.HasPrefix(path, "~/") {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			path = filepath.Join(homeDir, path[2:])
+		}
+		args = append(args, "--bind-try", path, path)
+	}
+
+	return append(args, "--chdir", dir, "--")
+}
+
+// cappedBuffer keeps the first and the last limit/2 bytes written to it.
+type cappedBuffer struct {
+	limit int
+	head  []byte
+	tail  []byte
+	total int64
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += int64(n)
+
+	half := b.limit / 2
+	if room := half - len(b.head); room > 0 {
+		take := min(room, len(p))
+		b.head = append(b.head, p[:take]...)
+		p = p[take:]
+	}
+
+	b.tail = append(b.tail, p...)
+	if len(b.tail) > half {
+		b.tail = append([]byte(nil), b.tail[len(b.tail)-half:]...)
+	}
+	return n, nil
+}
+
+func (b *cappedBuffer) String() string {
+	kept := int64(len(b.head) + len(b.tail))
+	if b.total == kept {
+		return strings.ToValidUTF8(string(b.head)+string(b.tail), "�")
+	}
+	return fmt.Sprintf("%s\n... [%s omitted] ...\n%s",
+		strings.ToValidUTF8(string(trimPartialRune(b.head)), "�"),
+		formatSize(b.total-kept),
+		strings.ToValidUTF8(string(b.tail), "�"))
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -11,7 +12,13 @@ type ToolDefinition struct {
 	Name        string                         `json:"name"`
 	Description string                         `json:"description"`
 	InputSchema anthropic.ToolInputSchemaParam `json:"input_schema"`
-	Function    func(input json.RawMessage) (string, error)
+	Function    func(ctx context.Context, input json.RawMessage) (string, error)
+	// ReadOnly tools never modify files or git state, so they are safe to
+	// run concurrently and are allowed without asking.
+	ReadOnly bool `json:"-"`
+	// Paths lists the files a call touches when they are not plain path
+	// fields of its input, so permission rules can match them.
+	Paths func(input json.RawMessage) []string `json:"-"`
 }
 
 func generateSchema[T any]() anthropic.ToolInputSchemaParam {
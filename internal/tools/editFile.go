@@ -1,35 +1,19 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"strings"
-	"sync"
 )
 
 type EditFileInput struct {
-	Path   string `json:"path" jsonschema_description:"The path to the file"`
-	OldStr string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
-	NewStr string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
-}
-
-var (
-	readFilesMutex sync.RWMutex
-	readFiles      = make(map[string]bool)
-)
-
-func MarkFileAsRead(filePath string) {
-	readFilesMutex.Lock()
-	defer readFilesMutex.Unlock()
-	readFiles[filePath] = true
-}
-
-func hasBeenRead(filePath string) bool {
-	readFilesMutex.RLock()
-	defer readFilesMutex.RUnlock()
-	return readFiles[filePath]
+	Path       string `json:"path" jsonschema_description:"The path to the file"`
+	OldStr     string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
+	NewStr     string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+	ReplaceAll bool   `json:"replace_all,omitempty" jsonschema_description:"Replace every occurrence of old_str instead of requiring a single match. Defaults to false"`
 }
 
 var (
@@ -41,6 +25,8 @@ var (
 IMPORTANT: You MUST use read_file first to see the current contents before editing any existing file. This tool will fail if you attempt to edit an existing file without reading it first.
 
 Replaces 'old_str' with 'new_str' in the given file. 'old_str' and 'new_str' MUST be different from each other.
+'old_str' must match exactly one location; include enough surrounding lines to make it unique, or set replace_all=true to replace every occurrence.
+Returns a unified diff of the change.
 
 If the file doesn't exist, it will be created (no need to read first for new files).
 `,
@@ -49,7 +35,7 @@ If the file doesn't exist, it will be created (no need to read first for new fil
 	}
 )
 
-func EditFile(input json.RawMessage) (string, error) {
+func EditFile(ctx context.Context, input json.RawMessage) (string, error) {
 	editFileInput := EditFileInput{}
 	if err := json.Unmarshal(input, &editFileInput); err != nil {
 		return "", err
@@ -67,21 +53,146 @@ func EditFile(input json.RawMessage) (string, error) {
 		return "", err
 	}
 
-	if !hasBeenRead(editFileInput.Path) {
-		return "", fmt.Errorf("ERROR: File '%s' exists but you haven't read it yet. You MUST use the read_file tool first to see the current contents before editing. This prevents accidental duplications or overwrites. Use: read_file with path '%s' then try edit_file again", editFileInput.Path, editFileInput.Path)
+	if err := checkFreshRead(editFileInput.Path, "edit_file"); err != nil {
+		return "", err
 	}
 
 	oldContent := string(content)
-	newContent := strings.ReplaceAll(oldContent, editFileInput.OldStr, editFileInput.NewStr)
-	if oldContent == newContent && editFileInput.OldStr != "" {
-		return "", fmt.Errorf("old_str not found in file")
+	newContent, err := replaceInContent(oldContent, editFileInput.OldStr, editFileInput.NewStr, editFileInput.ReplaceAll)
+	if err != nil {
+		return "", err
 	}
 
 	if err := os.WriteFile(editFileInput.Path, []byte(newContent), 0644); err != nil {
 		return "", err
 	}
+	MarkFileAsRead(editFileInput.Path)
+
+	return unifiedDiff(editFileInput.Path, oldContent, newContent), nil
+}
+
+type EditOperation struct {
+	OldStr     string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
+	NewStr     string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+	ReplaceAll bool   `json:"replace_all,omitempty" jsonschema_description:"Replace every occurrence of old_str instead of requiring a single match. Defaults to false"`
+}
+
+type MultiEditInput struct {
+	Path  string          `json:"path" jsonschema_description:"The path to the file"`
+	Edits []EditOperation `json:"edits" jsonschema_description:"Edits to apply in order. Each edit sees the result of the previous ones"`
+}
+
+var (
+	MultiEditInputSchema = generateSchema[MultiEditInput]()
+	MultiEditDefinition  = ToolDefinition{
+		Name: "multi_edit",
+		Description: `Make several edits to a single file in one operation.
+
+IMPORTANT: You MUST use read_file first to see the current contents before editing any existing file.
+
+Applies each {old_str, new_str, replace_all} edit in order, with the same rules as edit_file. Later edits operate on the result of earlier ones.
+The edits are atomic: if any edit fails, the file is left untouched. Returns a unified diff of the combined change.
+
+If the file doesn't exist, the first edit may use an empty old_str to create it.
+`,
+		InputSchema: MultiEditInputSchema,
+		Function:    MultiEdit,
+	}
+)
+
+func MultiEdit(ctx context.Context, input json.RawMessage) (string, error) {
+	multiEditInput := MultiEditInput{}
+	if err := json.Unmarshal(input, &multiEditInput); err != nil {
+		return "", err
+	}
 
-	return "OK", nil
+	if multiEditInput.Path == "" || len(multiEditInput.Edits) == 0 {
+		return "", fmt.Errorf("invalid input parameters")
+	}
+
+	edits := multiEditInput.Edits
+	exists := true
+	content, err := os.ReadFile(multiEditInput.Path)
+	if err != nil {
+		if !os.IsNotExist(err) || edits[0].OldStr != "" {
+			return "", err
+		}
+		exists = false
+	} else if err := checkFreshRead(multiEditInput.Path, "multi_edit"); err != nil {
+		return "", err
+	}
+
+	oldContent := string(content)
+	newContent := oldContent
+	start := 0
+	if !exists {
+		newContent = edits[0].NewStr
+		start = 1
+	}
+
+	for i := start; i < len(edits); i++ {
+		edit := edits[i]
+		if edit.OldStr == edit.NewStr {
+			return "", fmt.Errorf("edit %d: old_str and new_str must be different; no changes were written", i+1)
+		}
+		newContent, err = replaceInContent(newContent, edit.OldStr, edit.NewStr, edit.ReplaceAll)
+		if err != nil {
+			return "", fmt.Errorf("edit %d: %w; no changes were written", i+1, err)
+		}
+	}
+
+	if !exists {
+		return createNewFile(multiEditInput.Path, newContent)
+	}
+
+	if err := os.WriteFile(multiEditInput.Path, []byte(newContent), 0644); err != nil {
+		return "", err
+	}
+	MarkFileAsRead(multiEditInput.Path)
+
+	return unifiedDiff(multiEditInput.Path, oldContent, newContent), nil
+}
+
+// replaceInContent replaces oldStr with newStr, requiring a single match
+// unless replaceAll is set.
+func replaceInContent(content, oldStr, newStr string, replaceAll bool) (string, error) {
+	if oldStr == "" {
+		return "", fmt.Errorf("old_str must not be empty when editing an existing file")
+	}
+
+	count := strings.Count(content, oldStr)
+	if count == 0 {
+		return "", fmt.Errorf("old_str not found in file")
+	}
+	if count > 1 && !replaceAll {
+		return "", fmt.Errorf("old_str matches %d times (at lines %s). Include more surrounding context to make it unique, or set replace_all=true to replace every occurrence", count, matchLines(content, oldStr))
+	}
+
+	if replaceAll {
+		return strings.ReplaceAll(content, oldStr, newStr), nil
+	}
+	return strings.Replace(content, oldStr, newStr, 1), nil
+}
+
+func matchLines(content, substr string) string {
+	lines := []string{}
+	offset := 0
+	line := 1
+	for {
+		index := strings.Index(content[offset:], substr)
+		if index == -1 {
+			break
+		}
+		line += strings.Count(content[offset:offset+index], "\n")
+		if len(lines) == 10 {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d", line))
+		line += strings.Count(substr, "\n")
+		offset += index + len(substr)
+	}
+	return strings.Join(lines, ", ")
 }
 
 func createNewFile(filePath, content string) (string, error) {
@@ -97,6 +208,7 @@ func createNewFile(filePath, content string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to create file: %w", err)
 	}
+	MarkFileAsRead(filePath)
 
 	return fmt.Sprintf("Successfully created file %s", filePath), nil
 }
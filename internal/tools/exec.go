@@ -0,0 +1,16 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// command builds an external command that is killed when ctx is done. The
+// wait delay makes sure a child that keeps the output pipes open (such as a
+// git hook or a GPG pinentry) can't block the tool after cancellation.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = 2 * time.Second
+	return cmd
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -42,10 +43,11 @@ Examples:
 `,
 		InputSchema: FdInputSchema,
 		Function:    Fd,
+		ReadOnly:    true,
 	}
 )
 
-func Fd(input json.RawMessage) (string, error) {
+func Fd(ctx context.Context, input json.RawMessage) (string, error) {
 	fdInput := FdInput{}
 	if err := json.Unmarshal(input, &fdInput); err != nil {
 		return "", err
@@ -88,19 +90,25 @@ func Fd(input json.RawMessage) (string, error) {
 		args = append(args, ".")
 	}
 
-	cmd := exec.Command("fd", args...)
-	output, err := cmd.Output()
-	if err != nil {
-		if err.Error() == "exec: \"fd\" not found in $PATH" {
-			return "", fmt.Errorf("fd is not installed. Please install it first")
+	var result string
+	if _, err := exec.LookPath("fd"); err != nil {
+		output, err := nativeFd(ctx, fdInput, maxResults+1)
+		if err != nil {
+			return "", err
 		}
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return "No matches found", nil
+		result = output
+	} else {
+		cmd := command(ctx, "fd", args...)
+		output, err := cmd.Output()
+		if err != nil {
+			if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+				return "No matches found", nil
+			}
+			return "", fmt.Errorf("fd error: %w", err)
 		}
-		return "", fmt.Errorf("fd error: %w", err)
+		result = strings.TrimSpace(string(output))
 	}
 
-	result := strings.TrimSpace(string(output))
 	if result == "" {
 		return "No matches found", nil
 	}
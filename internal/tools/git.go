@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -26,10 +27,11 @@ Examples:
 `,
 		InputSchema: GitStatusInputSchema,
 		Function:    GitStatus,
+		ReadOnly:    true,
 	}
 )
 
-func GitStatus(input json.RawMessage) (string, error) {
+func GitStatus(ctx context.Context, input json.RawMessage) (string, error) {
 	gitInput := GitStatusInput{}
 	if err := json.Unmarshal(input, &gitInput); err != nil {
 		return "", err
@@ -40,7 +42,7 @@ func GitStatus(input json.RawMessage) (string, error) {
 		args = append(args, "--short")
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := command(ctx, "git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		if err.Error() == "exec: \"git\" not found in $PATH" {
@@ -83,7 +85,7 @@ Examples:
 	}
 )
 
-func GitAdd(input json.RawMessage) (string, error) {
+func GitAdd(ctx context.Context, input json.RawMessage) (string, error) {
 	gitInput := GitAddInput{}
 	if err := json.Unmarshal(input, &gitInput); err != nil {
 		return "", err
@@ -99,7 +101,7 @@ func GitAdd(input json.RawMessage) (string, error) {
 		args = append(args, gitInput.Paths...)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := command(ctx, "git", args...)
 	_, err := cmd.Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -136,7 +138,7 @@ Examples:
 	}
 )
 
-func GitCommit(input json.RawMessage) (string, error) {
+func GitCommit(ctx context.Context, input json.RawMessage) (string, error) {
 	gitInput := GitCommitInput{}
 	if err := json.Unmarshal(input, &gitInput); err != nil {
 		return "", err
@@ -151,7 +153,7 @@ func GitCommit(input json.RawMessage) (string, error) {
 		args = []string{"commit", "--amend", "-m", gitInput.Message}
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := command(ctx, "git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -187,10 +189,11 @@ Examples:
 `,
 		InputSchema: GitDiffInputSchema,
 		Function:    GitDiff,
+		ReadOnly:    true,
 	}
 )
 
-func GitDiff(input json.RawMessage) (string, error) {
+func GitDiff(ctx context.Context, input json.RawMessage) (string, error) {
 	gitInput := GitDiffInput{}
 	if err := json.Unmarshal(input, &gitInput); err != nil {
 		return "", err
@@ -211,7 +214,7 @@ func GitDiff(input json.RawMessage) (string, error) {
 		args = append(args, gitInput.Paths...)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := command(ctx, "git", args...)
 	output, err := cmd.Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
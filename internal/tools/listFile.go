@@ -1,30 +1,44 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type ListFilesInput struct {
-	Path string `json:"path,omitempty" jsonschema_description:"Optional relative path to list files from. Defaults to current directory if not provided."`
+	Path       string   `json:"path,omitempty" jsonschema_description:"Optional relative path to list files from. Defaults to current directory if not provided."`
+	MaxDepth   int      `json:"max_depth,omitempty" jsonschema_description:"Maximum directory depth to descend into; 1 lists only the direct children. 0 means no limit"`
+	Ignore     []string `json:"ignore,omitempty" jsonschema_description:"Extra globs to skip, in addition to .gitignore (e.g. '*.min.js', 'testdata/**')"`
+	MaxResults int      `json:"max_results,omitempty" jsonschema_description:"Maximum number of entries to return. Defaults to 500"`
+	Tree       bool     `json:"tree,omitempty" jsonschema_description:"Show an indented tree with file sizes instead of a JSON array of paths. Defaults to false"`
 }
 
 var (
 	ListFilesInputSchema = generateSchema[ListFilesInput]()
 	ListFilesDefinition  = ToolDefinition{
 		Name:        "list_files",
-		Description: "List files and directories at a given path. If no path is provided, lists files in the current directory.",
+		Description: "List files and directories at a given path. If no path is provided, lists files in the current directory. Entries ignored by .gitignore and the .git directory are skipped. Use max_depth to stay shallow, ignore to skip more paths, and tree=true for an indented view with file sizes.",
 		InputSchema: ListFilesInputSchema,
 		Function:    ListFiles,
+		ReadOnly:    true,
 	}
 )
 
-func ListFiles(input json.RawMessage) (string, error) {
+type listEntry struct {
+	rel   string
+	isDir bool
+	size  int64
+}
+
+func ListFiles(ctx context.Context, input json.RawMessage) (string, error) {
 	listFilesInput := ListFilesInput{}
-	err := json.Unmarshal(input, &listFilesInput)
-	if err != nil {
-		panic(err)
+	if err := json.Unmarshal(input, &listFilesInput); err != nil {
+		return "", err
 	}
 
 	dir := "."
@@ -32,34 +46,131 @@ func ListFiles(input json.RawMessage) (string, error) {
 		dir = listFilesInput.Path
 	}
 
-	var files []string
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	maxResults := listFilesInput.MaxResults
+	if maxResults <= 0 {
+		maxResults = 500
+	}
+
+	entries := []listEntry{}
+	truncated := false
+	err = walkSearchRoot(ctx, dir, walkOptions{hidden: true, maxDepth: listFilesInput.MaxDepth}, func(path, display string, entry fs.DirEntry, depth int) error {
+		if depth == 0 {
+			return nil
 		}
 
 		relPath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return err
 		}
+		relPath = filepath.ToSlash(relPath)
 
-		if relPath != "." {
-			if info.IsDir() {
-				files = append(files, relPath+"/")
-			} else {
-				files = append(files, relPath)
+		if matchesSearchGlobs(listFilesInput.Ignore, relPath, entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+
+		if len(entries) == maxResults {
+			truncated = true
+			return fs.SkipAll
 		}
+
+		e := listEntry{rel: relPath, isDir: entry.IsDir()}
+		if !e.isDir {
+			if info, err := entry.Info(); err == nil {
+				e.size = info.Size()
+			}
+		}
+		entries = append(entries, e)
 		return nil
 	})
 	if err != nil {
 		return "", err
 	}
 
-	result, err := json.Marshal(files)
-	if err != nil {
-		return "", err
+	var result string
+	if listFilesInput.Tree {
+		result = renderTree(dir, entries)
+	} else {
+		files := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if e.isDir {
+				files = append(files, e.rel+"/")
+			} else {
+				files = append(files, e.rel)
+			}
+		}
+		output, err := json.Marshal(files)
+		if err != nil {
+			return "", err
+		}
+		result = string(output)
+	}
+
+	if truncated {
+		result += fmt.Sprintf("\n... (showing first %d entries; use path, max_depth or ignore to narrow the listing, or raise max_results)", maxResults)
+	}
+	return result, nil
+}
+
+// renderTree draws entries, which arrive in walk order, as an indented tree.
+func renderTree(root string, entries []listEntry) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSuffix(root, "/") + "/\n")
+
+	for i, e := range entries {
+		parts := strings.Split(e.rel, "/")
+		depth := len(parts) - 1
+
+		for level := 0; level < depth; level++ {
+			if hasLaterSibling(entries, i, parts[:level+1]) {
+				sb.WriteString("│   ")
+			} else {
+				sb.WriteString("    ")
+			}
+		}
+		if hasLaterSibling(entries, i, parts) {
+			sb.WriteString("├── ")
+		} else {
+			sb.WriteString("└── ")
+		}
+
+		name := parts[depth]
+		if e.isDir {
+			sb.WriteString(name + "/\n")
+		} else {
+			fmt.Fprintf(&sb, "%s (%s)\n", name, formatSize(e.size))
+		}
 	}
 
-	return string(result), nil
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// hasLaterSibling reports whether an entry after i shares the parent of the
+// path given by parts, i.e. whether that path is not the last in its
+// directory.
+func hasLaterSibling(entries []listEntry, i int, parts []string) bool {
+	parent := strings.Join(parts[:len(parts)-1], "/")
+	for _, e := range entries[i+1:] {
+		entryParent := ""
+		if slash := strings.LastIndex(e.rel, "/"); slash >= 0 {
+			entryParent = e.rel[:slash]
+		}
+		if entryParent == parent {
+			return true
+		}
+		if parent != "" && entryParent != parent && !strings.HasPrefix(entryParent, parent+"/") {
+			return false
+		}
+	}
+	return false
 }
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -33,7 +34,7 @@ Examples:
 	}
 )
 
-func Mv(input json.RawMessage) (string, error) {
+func Mv(ctx context.Context, input json.RawMessage) (string, error) {
 	mvInput := MvInput{}
 	if err := json.Unmarshal(input, &mvInput); err != nil {
 		return "", err
@@ -0,0 +1,426 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/carlosarraes/lit/internal/gitignore"
+	"github.com/carlosarraes/lit/internal/glob"
+)
+
+// The native backends stand in for rg and fd when the binaries are not on
+// PATH. They follow the same defaults (hidden and ignored paths are skipped,
+// binary files are not searched) and print results the same way.
+
+// fileTypes maps the most common ripgrep type names to their globs.
+var fileTypes = map[string][]string{
+	"c":        {"*.c", "*.h"},
+	"cpp":      {"*.cpp", "*.cc", "*.cxx", "*.c++", "*.hpp", "*.hh", "*.hxx", "*.h++", "*.h", "*.inl"},
+	"cs":       {"*.cs"},
+	"css":      {"*.css", "*.scss"},
+	"docker":   {"Dockerfile", "Dockerfile.*", "*.dockerfile"},
+	"go":       {"*.go"},
+	"html":     {"*.html", "*.htm", "*.xhtml"},
+	"java":     {"*.java", "*.jsp"},
+	"js":       {"*.js", "*.jsx", "*.mjs", "*.cjs", "*.vue"},
+	"json":     {"*.json", "*.jsonl", "*.sarif"},
+	"kotlin":   {"*.kt", "*.kts"},
+	"lua":      {"*.lua"},
+	"make":     {"Makefile", "makefile", "GNUmakefile", "*.mk", "*.mak"},
+	"markdown": {"*.md", "*.markdown", "*.mdx", "*.mkd", "*.mkdn"},
+	"md":       {"*.md", "*.markdown", "*.mdx", "*.mkd", "*.mkdn"},
+	"php":      {"*.php", "*.php3", "*.php4", "*.php5", "*.phtml"},
+	"proto":    {"*.proto"},
+	"py":       {"*.py", "*.pyi"},
+	"rb":       {"*.rb", "*.gemspec", "Gemfile", "Rakefile"},
+	"ruby":     {"*.rb", "*.gemspec", "Gemfile", "Rakefile"},
+	"rust":     {"*.rs"},
+	"sh":       {"*.sh", "*.bash", "*.zsh", "*.ksh", ".bashrc", ".zshrc", ".profile"},
+	"sql":      {"*.sql", "*.psql"},
+	"swift":    {"*.swift"},
+	"toml":     {"*.toml", "Cargo.lock"},
+	"ts":       {"*.ts", "*.tsx", "*.cts", "*.mts"},
+	"txt":      {"*.txt"},
+	"xml":      {"*.xml", "*.xsd", "*.xsl", "*.xslt", "*.svg"},
+	"yaml":     {"*.yaml", "*.yml"},
+}
+
+func matchesAny(globs []string, name string) bool {
+	for _, pattern := range globs {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+type walkOptions struct {
+	hidden   bool
+	maxDepth int
+}
+
+// walkSearchRoot calls visit for root and everything below it the way rg and
+// fd traverse a directory: hidden entries (unless requested) and gitignored
+// paths are skipped and .git is never entered. display is the path as the
+// external tools would print it. visit may return fs.SkipAll to stop early.
+func walkSearchRoot(ctx context.Context, root string, opts walkOptions, visit func(path, display string, entry fs.DirEntry, depth int) error) error {
+	base := root
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		base = filepath.Dir(root)
+	}
+	matcher := gitignore.New(base)
+	// A root that is itself ignored was asked for explicitly, so its
+	// contents are walked as if it weren't.
+	useGitignore := !ignoredByGit(matcher, base, true)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		depth := 0
+		if rel != "." {
+			depth = strings.Count(rel, "/") + 1
+		}
+
+		if depth > 0 {
+			name := entry.Name()
+			if name == ".git" || (!opts.hidden && strings.HasPrefix(name, ".")) || (useGitignore && ignoredByGit(matcher, path, entry.IsDir())) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if opts.maxDepth > 0 && depth > opts.maxDepth {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		return visit(path, displayPath(root, rel), entry, depth)
+	})
+	if err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func ignoredByGit(matcher *gitignore.Matcher, path string, isDir bool) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return matcher.Ignored(abs, isDir)
+}
+
+func displayPath(root, rel string) string {
+	if rel == "." {
+		return root
+	}
+	if strings.HasSuffix(root, "/") {
+		return root + rel
+	}
+	return root + "/" + rel
+}
+
+// nativeRipgrep searches like rg, feeding matches, context lines, paths or
+// counts into results depending on the output mode.
+func nativeRipgrep(ctx context.Context, ripgrepInput RipgrepInput, results *searchResults) error {
+	pattern := ripgrepInput.Pattern
+	if ripgrepInput.FixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ripgrepInput.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !ripgrepInput.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("ripgrep error: %w", err)
+	}
+
+	var types []string
+	if ripgrepInput.FileType != "" {
+		var ok bool
+		if types, ok = fileTypes[ripgrepInput.FileType]; !ok {
+			return fmt.Errorf("ripgrep error: unrecognized file type: %s", ripgrepInput.FileType)
+		}
+	}
+
+	include := []string{}
+	exclude := []string{}
+	for _, filter := range ripgrepInput.Glob {
+		if strings.HasPrefix(filter, "!") {
+			exclude = append(exclude, filter[1:])
+		} else {
+			include = append(include, filter)
+		}
+	}
+	for _, filter := range ripgrepInput.ExcludeGlob {
+		exclude = append(exclude, strings.TrimPrefix(filter, "!"))
+	}
+
+	root := ripgrepInput.Path
+	if root == "" {
+		root = "."
+	}
+
+	err = walkSearchRoot(ctx, root, walkOptions{}, func(path, display string, entry fs.DirEntry, depth int) error {
+		if depth > 0 {
+			rel, _ := filepath.Rel(root, path)
+			rel = filepath.ToSlash(rel)
+			if matchesSearchGlobs(exclude, rel, entry) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			if len(include) > 0 && !matchesSearchGlobs(include, rel, entry) {
+				return nil
+			}
+			if types != nil && !matchesAny(types, entry.Name()) {
+				return nil
+			}
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		grepFile(path, display, re, ripgrepInput, results)
+		if results.truncated {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ripgrep error: %w", err)
+	}
+	return nil
+}
+
+// matchesSearchGlobs reports whether any glob matches the entry. Like rg, a
+// glob without a slash matches the name at any depth; otherwise it matches
+// the path relative to the search root.
+func matchesSearchGlobs(globs []string, rel string, entry fs.DirEntry) bool {
+	for _, pattern := range globs {
+		for _, expanded := range expandBraces(pattern) {
+			if !strings.Contains(strings.TrimSuffix(expanded, "/"), "/") {
+				if glob.Match(strings.TrimSuffix(expanded, "/"), entry.Name()) {
+					return true
+				}
+				continue
+			}
+			expanded = strings.TrimPrefix(expanded, "/")
+			if glob.Match(expanded, rel) || (entry.IsDir() && glob.Match(expanded, rel+"/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandBraces expands "{a,b}" alternatives, which rg globs support.
+func expandBraces(pattern string) []string {
+	open := strings.Index(pattern, "{")
+	if open < 0 {
+		return []string{pattern}
+	}
+	end := strings.Index(pattern[open:], "}")
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += open
+
+	expanded := []string{}
+	for _, alternative := range strings.Split(pattern[open+1:end], ",") {
+		expanded = append(expanded, expandBraces(pattern[:open]+alternative+pattern[end+1:])...)
+	}
+	return expanded
+}
+
+func grepFile(path, display string, re *regexp.Regexp, ripgrepInput RipgrepInput, results *searchResults) {
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), sniffLength)], 0) >= 0 {
+		return
+	}
+	content := string(data)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	matched := make([]bool, len(lines))
+	matches := 0
+	if ripgrepInput.Multiline {
+		lineStarts := []int{0}
+		for i, ch := range data {
+			if ch == '\n' {
+				lineStarts = append(lineStarts, i+1)
+			}
+		}
+		lineAt := func(offset int) int {
+			return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+		}
+		for _, loc := range re.FindAllStringIndex(content, -1) {
+			last := loc[1] - 1
+			if last < loc[0] {
+				last = loc[0]
+			}
+			for line := lineAt(loc[0]); line <= lineAt(last) && line < len(lines); line++ {
+				if !matched[line] {
+					matched[line] = true
+					matches++
+				}
+			}
+		}
+	} else {
+		for i, line := range lines {
+			if re.MatchString(line) {
+				matched[i] = true
+				matches++
+			}
+		}
+	}
+	if matches == 0 {
+		return
+	}
+
+	switch ripgrepInput.OutputMode {
+	case outputFilesWithMatches:
+		results.entry(display)
+		return
+	case outputCount:
+		results.entry(fmt.Sprintf("%s:%d", display, matches))
+		return
+	}
+
+	last := -1
+	for i := range lines {
+		if !matched[i] {
+			continue
+		}
+		start := max(i-ripgrepInput.ContextBefore, last+1)
+		if last < 0 || start > last+1 {
+			results.separator()
+		}
+		for j := start; j < i; j++ {
+			results.contextLine(display, j+1, lines[j])
+		}
+		if !results.match(display, i+1, lines[i]) {
+			return
+		}
+		last = i
+		for j := i + 1; j <= min(i+ripgrepInput.ContextAfter, len(lines)-1) && !matched[j]; j++ {
+			results.contextLine(display, j+1, lines[j])
+			last = j
+		}
+	}
+}
+
+// nativeFd lists entries whose name matches the pattern like fd does,
+// printing directories with a trailing slash. It stops after limit results.
+func nativeFd(ctx context.Context, fdInput FdInput, limit int) (string, error) {
+	var re *regexp.Regexp
+	if fdInput.Pattern != "" {
+		pattern := fdInput.Pattern
+		if !fdInput.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("fd error: %w", err)
+		}
+	}
+
+	switch fdInput.Type {
+	case "", "f", "file", "d", "directory", "dir", "l", "symlink", "x", "executable", "e", "empty":
+	default:
+		return "", fmt.Errorf("fd error: invalid value '%s' for type", fdInput.Type)
+	}
+
+	root := fdInput.Path
+	if root == "" {
+		root = "."
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("fd error: '%s' is not a directory", root)
+	}
+
+	results := []string{}
+	err := walkSearchRoot(ctx, root, walkOptions{hidden: fdInput.HiddenFiles, maxDepth: fdInput.MaxDepth}, func(path, display string, entry fs.DirEntry, depth int) error {
+		if depth == 0 {
+			return nil
+		}
+		name := entry.Name()
+		if re != nil && !re.MatchString(name) {
+			return nil
+		}
+		if fdInput.Extension != "" && !strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(strings.TrimPrefix(fdInput.Extension, "."))) {
+			return nil
+		}
+		if !matchesFdType(fdInput.Type, path, entry) {
+			return nil
+		}
+
+		if entry.IsDir() {
+			display += "/"
+		}
+		results = append(results, display)
+		if limit > 0 && len(results) >= limit {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("fd error: %w", err)
+	}
+
+	return strings.Join(results, "\n"), nil
+}
+
+func matchesFdType(fileType, path string, entry fs.DirEntry) bool {
+	switch fileType {
+	case "f", "file":
+		return entry.Type().IsRegular()
+	case "d", "directory", "dir":
+		return entry.IsDir()
+	case "l", "symlink":
+		return entry.Type()&fs.ModeSymlink != 0
+	case "x", "executable":
+		info, err := entry.Info()
+		return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+	case "e", "empty":
+		if entry.IsDir() {
+			entries, err := os.ReadDir(path)
+			return err == nil && len(entries) == 0
+		}
+		info, err := entry.Info()
+		return err == nil && info.Mode().IsRegular() && info.Size() == 0
+	}
+	return true
+}
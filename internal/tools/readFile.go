@@ -2,10 +2,24 @@ package tools
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineLength is how many bytes of a single line are shown before it is
+	// truncated, so minified files and lockfiles don't flood the context.
+	maxLineLength = 2000
+	sniffLength   = 8192
 )
 
 type ReadFileInput struct {
@@ -18,16 +32,17 @@ var (
 	ReadFileInputSchema = generateSchema[ReadFileInput]()
 	ReadFileDefinition  = ToolDefinition{
 		Name:        "read_file",
-		Description: "Read the contents of a given relative file path. For large files (>10k lines), automatically reads first 2000 lines. Use offset and limit parameters for specific sections. Do not use this with directory names.",
+		Description: "Read the contents of a given relative file path. For large files (>10k lines), automatically reads first 2000 lines. Use offset and limit parameters for specific sections. Lines longer than 2000 bytes are truncated. Binary files are reported by type and size instead of content. Do not use this with directory names.",
 		InputSchema: ReadFileInputSchema,
 		Function:    ReadFile,
+		ReadOnly:    true,
 	}
 )
 
-func ReadFile(input json.RawMessage) (string, error) {
+func ReadFile(ctx context.Context, input json.RawMessage) (string, error) {
 	readFileInput := ReadFileInput{}
 	if err := json.Unmarshal(input, &readFileInput); err != nil {
-		panic(err)
+		return "", err
 	}
 
 	file, err := os.Open(readFileInput.Path)
@@ -36,16 +51,40 @@ func ReadFile(input json.RawMessage) (string, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	totalLines := 0
-	for scanner.Scan() {
-		totalLines++
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
 	}
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error counting lines: %w", err)
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory, use list_files instead", readFileInput.Path)
 	}
 
-	file.Seek(0, 0)
+	hash := sha256.New()
+	reader := bufio.NewReaderSize(io.TeeReader(file, hash), sniffLength)
+
+	sample, _ := reader.Peek(sniffLength)
+	encoding := ""
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		encoding = "UTF-16LE"
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		encoding = "UTF-16BE"
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		encoding = "UTF-8 with BOM"
+		reader.Discard(3)
+	default:
+		if contentType := http.DetectContentType(sample); bytes.IndexByte(sample, 0) >= 0 || !strings.HasPrefix(contentType, "text/") {
+			return fmt.Sprintf("%s is a binary file (%s, %s); its content is not shown.", readFileInput.Path, strings.Split(contentType, ";")[0], formatSize(info.Size())), nil
+		}
+	}
+
+	if strings.HasPrefix(encoding, "UTF-16") {
+		raw, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("error reading file: %w", err)
+		}
+		reader = bufio.NewReader(strings.NewReader(decodeUTF16(raw[2:], encoding == "UTF-16BE")))
+	}
 
 	var offset, limit int
 	if readFileInput.Offset > 0 {
@@ -53,36 +92,57 @@ func ReadFile(input json.RawMessage) (string, error) {
 	} else {
 		offset = 1
 	}
-
 	if readFileInput.Limit > 0 {
 		limit = readFileInput.Limit
-	} else if totalLines > 10000 {
-		limit = 2000
-	} else {
-		limit = totalLines
 	}
 
-	scanner = bufio.NewScanner(file)
+	// Without an explicit limit every line is kept until the file turns out to
+	// have more than 10000 lines, then only the first 2000 are shown.
 	var lines []string
-	currentLine := 1
-	linesRead := 0
+	totalLines := 0
+	crlfLines := 0
+	truncatedLines := 0
+	for {
+		line, dropped, crlf, err := readLine(reader)
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("error reading file: %w", err)
+		}
+		if err == io.EOF && len(line) == 0 && dropped == 0 && !crlf {
+			break
+		}
 
-	for scanner.Scan() && linesRead < limit {
-		if currentLine >= offset {
-			lines = append(lines, fmt.Sprintf("%5d\t%s", currentLine, scanner.Text()))
-			linesRead++
+		totalLines++
+		if crlf {
+			crlfLines++
+		}
+		if readFileInput.Limit == 0 && limit == 0 && totalLines > 10000 {
+			limit = 2000
+			if len(lines) > limit {
+				lines = lines[:limit]
+			}
+		}
+		if totalLines >= offset && (limit == 0 || len(lines) < limit) {
+			text := string(line)
+			if dropped > 0 {
+				text += fmt.Sprintf(" ... [line truncated, %d more bytes]", dropped)
+				truncatedLines++
+			}
+			lines = append(lines, fmt.Sprintf("%5d\t%s", totalLines, text))
 		}
-		currentLine++
-	}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading file: %w", err)
+		if err == io.EOF {
+			break
+		}
 	}
+	if limit == 0 {
+		limit = totalLines
+	}
+	linesRead := len(lines)
 
-	MarkFileAsRead(readFileInput.Path)
+	recordRead(readFileInput.Path, info, hash.Sum(nil))
 
 	result := strings.Join(lines, "\n")
-	
+
 	if totalLines > 10000 && readFileInput.Limit == 0 && readFileInput.Offset == 0 {
 		result += fmt.Sprintf("\n\n⚠️  Large file detected (%d total lines). Showing first %d lines.\n", totalLines, limit)
 		result += "💡 Navigation tips:\n"
@@ -93,7 +153,7 @@ func ReadFile(input json.RawMessage) (string, error) {
 	} else if readFileInput.Offset > 0 || readFileInput.Limit > 0 {
 		endLine := offset + linesRead - 1
 		result += fmt.Sprintf("\n\n📍 Showing lines %d-%d of %d total lines.\n", offset, endLine, totalLines)
-		
+
 		if offset > 1 {
 			result += fmt.Sprintf("   - Previous section: offset=%d, limit=%d\n", max(1, offset-limit), limit)
 		}
@@ -105,9 +165,99 @@ func ReadFile(input json.RawMessage) (string, error) {
 		}
 	}
 
+	notes := []string{}
+	if encoding != "" {
+		notes = append(notes, fmt.Sprintf("File is encoded as %s; it is shown as UTF-8.", encoding))
+	}
+	switch {
+	case crlfLines > 0 && crlfLines >= totalLines-1:
+		notes = append(notes, "File uses CRLF (\\r\\n) line endings.")
+	case crlfLines > 0:
+		notes = append(notes, fmt.Sprintf("File has mixed line endings (%d of %d lines end with \\r\\n).", crlfLines, totalLines))
+	}
+	if truncatedLines > 0 {
+		notes = append(notes, fmt.Sprintf("%d long lines were truncated to %d bytes; use ripgrep to search inside them.", truncatedLines, maxLineLength))
+	}
+	if len(notes) > 0 {
+		result += "\n\n📝 " + strings.Join(notes, "\n📝 ")
+	}
+
 	return result, nil
 }
 
+// readLine reads the next line without its line ending, keeping at most
+// maxLineLength bytes and reporting how many more bytes were dropped.
+func readLine(reader *bufio.Reader) ([]byte, int, bool, error) {
+	var line []byte
+	length := 0
+	var last, beforeLast byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if room := maxLineLength + 2 - len(line); room > 0 {
+			line = append(line, chunk[:min(room, len(chunk))]...)
+		}
+		length += len(chunk)
+		if len(chunk) >= 2 {
+			beforeLast, last = chunk[len(chunk)-2], chunk[len(chunk)-1]
+		} else if len(chunk) == 1 {
+			beforeLast, last = last, chunk[0]
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		crlf := false
+		if last == '\n' && length > 0 {
+			length--
+			if beforeLast == '\r' && length > 0 {
+				length--
+				crlf = true
+			}
+		}
+		kept := line[:min(len(line), length, maxLineLength)]
+		if len(kept) < length {
+			kept = trimPartialRune(kept)
+		}
+		return kept, length - len(kept), crlf, err
+	}
+}
+
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+func decodeUTF16(raw []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		} else {
+			units = append(units, uint16(raw[i+1])<<8|uint16(raw[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
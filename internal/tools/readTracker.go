@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileState is what a file looked like when the model last saw it.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+var (
+	readFilesMutex sync.RWMutex
+	readFiles      = make(map[string]fileState)
+)
+
+func trackerKey(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		return abs
+	}
+	return filepath.Clean(filePath)
+}
+
+func statFile(filePath string) (fileState, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fileState{}, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(content)}, nil
+}
+
+// MarkFileAsRead records the current state of filePath so later edits can
+// detect whether the file changed since the model last saw it.
+func MarkFileAsRead(filePath string) {
+	state, err := statFile(filePath)
+	if err != nil {
+		return
+	}
+	setFileState(filePath, state)
+}
+
+// recordRead records a file whose content was hashed while it was read.
+func recordRead(filePath string, info os.FileInfo, digest []byte) {
+	state := fileState{modTime: info.ModTime(), size: info.Size()}
+	copy(state.hash[:], digest)
+	setFileState(filePath, state)
+}
+
+func setFileState(filePath string, state fileState) {
+	readFilesMutex.Lock()
+	defer readFilesMutex.Unlock()
+	readFiles[trackerKey(filePath)] = state
+}
+
+// checkFreshRead returns an error telling the model to (re-)read filePath
+// unless it has been read and is unchanged since.
+func checkFreshRead(filePath, toolName string) error {
+	readFilesMutex.RLock()
+	recorded, ok := readFiles[trackerKey(filePath)]
+	readFilesMutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("ERROR: File '%s' exists but you haven't read it yet. You MUST use the read_file tool first to see the current contents before editing. This prevents accidental duplications or overwrites. Use: read_file with path '%s' then try %s again", filePath, filePath, toolName)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if info.Size() == recorded.size && info.ModTime().Equal(recorded.modTime) {
+		return nil
+	}
+
+	current, err := statFile(filePath)
+	if err != nil {
+		return err
+	}
+	if current.hash != recorded.hash {
+		return fmt.Errorf("ERROR: File '%s' has changed since you last read it. Use read_file with path '%s' to see the current contents, then try %s again", filePath, filePath, toolName)
+	}
+	return nil
+}
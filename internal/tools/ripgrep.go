@@ -1,19 +1,37 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
+const (
+	outputContent          = "content"
+	outputFilesWithMatches = "files_with_matches"
+	outputCount            = "count"
+)
+
 type RipgrepInput struct {
-	Pattern     string `json:"pattern" jsonschema_description:"The regex pattern to search for"`
-	Path        string `json:"path,omitempty" jsonschema_description:"Optional path to search in. Defaults to current directory if not provided"`
-	CaseSensitive bool `json:"case_sensitive,omitempty" jsonschema_description:"Whether the search should be case sensitive. Defaults to false"`
-	WholeWord   bool   `json:"whole_word,omitempty" jsonschema_description:"Whether to match whole words only. Defaults to false"`
-	FileType    string `json:"file_type,omitempty" jsonschema_description:"Optional file type filter (e.g., 'go', 'js', 'py')"`
-	MaxResults  int    `json:"max_results,omitempty" jsonschema_description:"Maximum number of results to return. Defaults to 50"`
+	Pattern       string   `json:"pattern" jsonschema_description:"The regex pattern to search for"`
+	Path          string   `json:"path,omitempty" jsonschema_description:"Optional path to search in. Defaults to current directory if not provided"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty" jsonschema_description:"Whether the search should be case sensitive. Defaults to false"`
+	WholeWord     bool     `json:"whole_word,omitempty" jsonschema_description:"Whether to match whole words only. Defaults to false"`
+	FixedStrings  bool     `json:"fixed_strings,omitempty" jsonschema_description:"Treat the pattern as a literal string instead of a regex. Defaults to false"`
+	Multiline     bool     `json:"multiline,omitempty" jsonschema_description:"Allow matches to span lines; use \\n in the pattern to match a line break. Defaults to false"`
+	FileType      string   `json:"file_type,omitempty" jsonschema_description:"Optional file type filter (e.g., 'go', 'js', 'py')"`
+	Glob          []string `json:"glob,omitempty" jsonschema_description:"Only search files matching these globs (e.g. '*.go', 'src/**/*.{ts,tsx}'). A glob starting with '!' excludes instead"`
+	ExcludeGlob   []string `json:"exclude_glob,omitempty" jsonschema_description:"Skip files and directories matching these globs (e.g. 'vendor/**', '*_test.go')"`
+	ContextBefore int      `json:"context_before,omitempty" jsonschema_description:"Number of lines to show before each match. Only used with output_mode 'content'"`
+	ContextAfter  int      `json:"context_after,omitempty" jsonschema_description:"Number of lines to show after each match. Only used with output_mode 'content'"`
+	OutputMode    string   `json:"output_mode,omitempty" jsonschema_description:"'content' shows matching lines (default), 'files_with_matches' only the paths of matching files, 'count' the number of matching lines per file"`
+	MaxResults    int      `json:"max_results,omitempty" jsonschema_description:"Maximum number of results in total: matching lines, files or counts depending on output_mode. Defaults to 50"`
 }
 
 var (
@@ -23,21 +41,29 @@ var (
 		Description: `Search for patterns in files using ripgrep (rg).
 
 Searches for regex patterns across files in the specified directory (or current directory if not specified).
-Returns matching lines with file names and line numbers.
+Returns matching lines as path:line:text; context lines are shown as path-line-text and groups are separated by "--".
+max_results caps the total output across all files.
 
 Examples:
 - Search for "function" in all files: pattern="function"
-- Search in specific directory: pattern="TODO", path="src/"  
+- Search in specific directory: pattern="TODO", path="src/"
 - Case sensitive search: pattern="Error", case_sensitive=true
 - Filter by file type: pattern="import", file_type="go"
+- Filter by glob: pattern="useState", glob=["*.{ts,tsx}"], exclude_glob=["node_modules/**"]
+- Show surrounding lines: pattern="func main", context_before=2, context_after=10
+- Literal text: pattern="a.b(c)", fixed_strings=true
+- Span lines: pattern="if err != nil \\{\\n\\s+return", multiline=true
+- Only list files: pattern="TODO", output_mode="files_with_matches"
+- Count per file: pattern="TODO", output_mode="count"
 - Limit results: pattern="console.log", max_results=10
 `,
 		InputSchema: RipgrepInputSchema,
 		Function:    Ripgrep,
+		ReadOnly:    true,
 	}
 )
 
-func Ripgrep(input json.RawMessage) (string, error) {
+func Ripgrep(ctx context.Context, input json.RawMessage) (string, error) {
 	ripgrepInput := RipgrepInput{}
 	if err := json.Unmarshal(input, &ripgrepInput); err != nil {
 		return "", err
@@ -47,52 +73,239 @@ func Ripgrep(input json.RawMessage) (string, error) {
 		return "", fmt.Errorf("pattern is required")
 	}
 
+	switch ripgrepInput.OutputMode {
+	case "":
+		ripgrepInput.OutputMode = outputContent
+	case outputContent, outputFilesWithMatches, outputCount:
+	default:
+		return "", fmt.Errorf("invalid output_mode %q (supported: content, files_with_matches, count)", ripgrepInput.OutputMode)
+	}
+
+	if ripgrepInput.ContextBefore < 0 || ripgrepInput.ContextAfter < 0 {
+		return "", fmt.Errorf("context_before and context_after must not be negative")
+	}
+	if ripgrepInput.OutputMode != outputContent {
+		ripgrepInput.ContextBefore, ripgrepInput.ContextAfter = 0, 0
+	}
+
+	maxResults := ripgrepInput.MaxResults
+	if maxResults <= 0 {
+		maxResults = 50
+	}
+
+	results := &searchResults{
+		limit:   maxResults,
+		context: ripgrepInput.ContextBefore > 0 || ripgrepInput.ContextAfter > 0,
+	}
+
+	if _, err := exec.LookPath("rg"); err != nil {
+		if err := nativeRipgrep(ctx, ripgrepInput, results); err != nil {
+			return "", err
+		}
+		return results.String(), nil
+	}
+
+	if err := runRipgrep(ctx, ripgrepArgs(ripgrepInput), ripgrepInput.OutputMode, results); err != nil {
+		return "", err
+	}
+	return results.String(), nil
+}
+
+func ripgrepArgs(ripgrepInput RipgrepInput) []string {
 	args := []string{}
-	
+
 	if !ripgrepInput.CaseSensitive {
 		args = append(args, "-i")
 	}
-	
 	if ripgrepInput.WholeWord {
 		args = append(args, "-w")
 	}
-	
+	if ripgrepInput.FixedStrings {
+		args = append(args, "-F")
+	}
+	if ripgrepInput.Multiline {
+		args = append(args, "-U")
+	}
 	if ripgrepInput.FileType != "" {
 		args = append(args, "-t", ripgrepInput.FileType)
 	}
-	
-	args = append(args, "-n", "-H")
-	
-	maxResults := ripgrepInput.MaxResults
-	if maxResults == 0 {
-		maxResults = 50
+	for _, glob := range ripgrepInput.Glob {
+		args = append(args, "-g", glob)
+	}
+	for _, glob := range ripgrepInput.ExcludeGlob {
+		args = append(args, "-g", "!"+strings.TrimPrefix(glob, "!"))
+	}
+
+	switch ripgrepInput.OutputMode {
+	case outputFilesWithMatches:
+		args = append(args, "-l")
+	case outputCount:
+		args = append(args, "-c", "-H")
+	default:
+		// --null separates the path from the rest of the line so match and
+		// context lines can be told apart whatever the path contains.
+		args = append(args, "-n", "-H", "--null")
+		if ripgrepInput.ContextBefore > 0 {
+			args = append(args, "-B", strconv.Itoa(ripgrepInput.ContextBefore))
+		}
+		if ripgrepInput.ContextAfter > 0 {
+			args = append(args, "-A", strconv.Itoa(ripgrepInput.ContextAfter))
+		}
 	}
-	args = append(args, "-m", fmt.Sprintf("%d", maxResults))
-	
-	args = append(args, ripgrepInput.Pattern)
-	
+
+	args = append(args, "-e", ripgrepInput.Pattern)
+
 	if ripgrepInput.Path != "" {
 		args = append(args, ripgrepInput.Path)
 	} else {
 		args = append(args, ".")
 	}
+	return args
+}
+
+// runRipgrep streams rg's output into results and stops rg as soon as the
+// result cap is reached.
+func runRipgrep(ctx context.Context, args []string, mode string, results *searchResults) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	cmd := exec.Command("rg", args...)
-	output, err := cmd.Output()
+	cmd := command(ctx, "rg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		if err.Error() == "exec: \"rg\" not found in $PATH" {
-			return "", fmt.Errorf("ripgrep (rg) is not installed. Please install it first")
+		return fmt.Errorf("ripgrep error: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("ripgrep error: %w", err)
+	}
+
+	reader := bufio.NewReader(stdout)
+	for !results.truncated {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			results.addRipgrepLine(strings.TrimSuffix(line, "\n"), mode)
 		}
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
-			return "No matches found", nil
+		if err != nil {
+			break
 		}
-		return "", fmt.Errorf("ripgrep error: %w", err)
 	}
 
-	result := strings.TrimSpace(string(output))
-	if result == "" {
-		return "No matches found", nil
+	if results.truncated {
+		cancel()
+		cmd.Wait()
+		return nil
+	}
+
+	if err := cmd.Wait(); err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			// 1 means no matches; 2 with output means some files could not
+			// be read, which rg reports but doesn't stop for.
+			if exitError.ExitCode() == 1 || (exitError.ExitCode() == 2 && len(results.lines) > 0) {
+				return nil
+			}
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("ripgrep error: %s", message)
+		}
+		return fmt.Errorf("ripgrep error: %w", err)
+	}
+	return nil
+}
+
+// searchResults collects ripgrep-style output and enforces the global cap on
+// matching lines (content mode) or entries (files and count modes).
+type searchResults struct {
+	limit     int
+	context   bool
+	count     int
+	lines     []string
+	lastMatch int
+	lastBreak int
+	truncated bool
+}
+
+func (r *searchResults) addRipgrepLine(line, mode string) {
+	if mode != outputContent {
+		r.entry(line)
+		return
+	}
+	if line == "--" {
+		r.separator()
+		return
+	}
+
+	path, rest, ok := strings.Cut(line, "\x00")
+	if !ok {
+		return
+	}
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
 	}
+	if digits == 0 || digits == len(rest) {
+		return
+	}
+	lineNumber, _ := strconv.Atoi(rest[:digits])
+	if rest[digits] == ':' {
+		r.match(path, lineNumber, rest[digits+1:])
+	} else {
+		r.contextLine(path, lineNumber, rest[digits+1:])
+	}
+}
 
-	return result, nil
+func (r *searchResults) match(path string, lineNumber int, text string) bool {
+	if r.truncated {
+		return false
+	}
+	if r.count >= r.limit {
+		r.truncated = true
+		// Drop the leading context of the match that didn't fit.
+		if r.lastBreak >= r.lastMatch && r.lastBreak > 0 {
+			r.lines = r.lines[:r.lastBreak]
+		}
+		return false
+	}
+	r.count++
+	r.lines = append(r.lines, fmt.Sprintf("%s:%d:%s", path, lineNumber, text))
+	r.lastMatch = len(r.lines)
+	return true
+}
+
+func (r *searchResults) contextLine(path string, lineNumber int, text string) {
+	if !r.truncated {
+		r.lines = append(r.lines, fmt.Sprintf("%s-%d-%s", path, lineNumber, text))
+	}
+}
+
+func (r *searchResults) separator() {
+	if !r.truncated && r.context && len(r.lines) > 0 {
+		r.lastBreak = len(r.lines)
+		r.lines = append(r.lines, "--")
+	}
+}
+
+func (r *searchResults) entry(line string) bool {
+	if r.truncated {
+		return false
+	}
+	if r.count >= r.limit {
+		r.truncated = true
+		return false
+	}
+	r.count++
+	r.lines = append(r.lines, line)
+	return true
+}
+
+func (r *searchResults) String() string {
+	if len(r.lines) == 0 {
+		return "No matches found"
+	}
+	result := strings.Join(r.lines, "\n")
+	if r.truncated {
+		result += fmt.Sprintf("\n... (showing first %d results; narrow the search or raise max_results)", r.limit)
+	}
+	return result
 }
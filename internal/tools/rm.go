@@ -1,11 +1,10 @@
 package tools
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 )
 
 type RmInput struct {
@@ -18,10 +17,10 @@ var (
 	RmInputSchema = generateSchema[RmInput]()
 	RmDefinition  = ToolDefinition{
 		Name: "rm",
-		Description: `Remove files and directories with confirmation prompt.
+		Description: `Remove files and directories.
 
-SAFETY: This tool will always show what will be removed and ask for user confirmation (y/N).
-The user must explicitly type 'y' or 'yes' to proceed. Defaults to 'N' (no).
+SAFETY: Unless the user allowed it beforehand, they will be shown what will be removed and asked for confirmation.
+If the user declines, the removal is not performed.
 
 Examples:
 - Remove a file: path="old_file.txt"
@@ -33,7 +32,7 @@ Examples:
 	}
 )
 
-func Rm(input json.RawMessage) (string, error) {
+func Rm(ctx context.Context, input json.RawMessage) (string, error) {
 	rmInput := RmInput{}
 	if err := json.Unmarshal(input, &rmInput); err != nil {
 		return "", err
@@ -51,29 +50,6 @@ func Rm(input json.RawMessage) (string, error) {
 		return "", fmt.Errorf("error checking path: %w", err)
 	}
 
-	command := "rm"
-	if rmInput.Recursive {
-		command += " -r"
-	}
-	if rmInput.Force {
-		command += " -f"
-	}
-	command += " " + rmInput.Path
-
-	fmt.Printf("\n⚠️  About to execute: %s\n", command)
-	fmt.Print("Are you sure you want to proceed? (y/N): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return "", fmt.Errorf("error reading user input: %w", err)
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response != "y" && response != "yes" {
-		return "❌ Operation cancelled by user", nil
-	}
-
 	err = os.RemoveAll(rmInput.Path)
 	if err != nil {
 		return "", fmt.Errorf("failed to remove %s: %w", rmInput.Path, err)
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type WriteFileInput struct {
+	Path    string `json:"path" jsonschema_description:"The path to the file"`
+	Content string `json:"content" jsonschema_description:"The full content of the file"`
+}
+
+var (
+	WriteFileInputSchema = generateSchema[WriteFileInput]()
+	WriteFileDefinition  = ToolDefinition{
+		Name: "write_file",
+		Description: `Create a file or replace its entire content.
+
+IMPORTANT: To overwrite an existing file you MUST use read_file on it first. Prefer edit_file or multi_edit for partial changes.
+
+Missing parent directories are created. When overwriting, the file's permissions and whether it ends with a newline are kept.
+Returns a unified diff when an existing file is overwritten.
+`,
+		InputSchema: WriteFileInputSchema,
+		Function:    WriteFile,
+	}
+)
+
+func WriteFile(ctx context.Context, input json.RawMessage) (string, error) {
+	writeFileInput := WriteFileInput{}
+	if err := json.Unmarshal(input, &writeFileInput); err != nil {
+		return "", err
+	}
+
+	if writeFileInput.Path == "" {
+		return "", fmt.Errorf("invalid input parameters")
+	}
+
+	target := writeFileInput.Path
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	info, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		if dir := filepath.Dir(target); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return "", fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		if err := writeFileAtomic(target, []byte(writeFileInput.Content), 0644); err != nil {
+			return "", fmt.Errorf("failed to create file: %w", err)
+		}
+		MarkFileAsRead(writeFileInput.Path)
+		return fmt.Sprintf("Created %s (%d lines)", writeFileInput.Path, len(splitLines(writeFileInput.Content))), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", writeFileInput.Path)
+	}
+
+	if err := checkFreshRead(writeFileInput.Path, "write_file"); err != nil {
+		return "", err
+	}
+
+	oldContent, err := os.ReadFile(target)
+	if err != nil {
+		return "", err
+	}
+
+	newContent := writeFileInput.Content
+	if newContent != "" && len(oldContent) > 0 {
+		hadNewline := strings.HasSuffix(string(oldContent), "\n")
+		hasNewline := strings.HasSuffix(newContent, "\n")
+		switch {
+		case hadNewline && !hasNewline:
+			newContent += "\n"
+		case !hadNewline && hasNewline:
+			newContent = strings.TrimSuffix(newContent, "\n")
+		}
+	}
+
+	if newContent == string(oldContent) {
+		return fmt.Sprintf("No changes to %s", writeFileInput.Path), nil
+	}
+
+	if err := writeFileAtomic(target, []byte(newContent), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	MarkFileAsRead(writeFileInput.Path)
+
+	return unifiedDiff(writeFileInput.Path, string(oldContent), newContent), nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}